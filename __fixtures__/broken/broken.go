@@ -0,0 +1,7 @@
+package broken
+
+var A int = "A"
+
+func Print() string {
+	return B
+}
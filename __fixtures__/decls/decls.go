@@ -0,0 +1,33 @@
+package decls
+
+// Kind of animal
+// +gengo:enum
+type Kind int
+
+const (
+	// KindCat cat
+	KindCat Kind = iota + 1
+	// KindDog dog
+	KindDog
+	kindUnknown
+)
+
+// Max is not Kind
+const Max = 3
+
+var defaultKind = KindCat
+
+// String of Kind
+func (k Kind) String() string {
+	return ""
+}
+
+func (k *Kind) set(v Kind) {
+	*k = v
+}
+
+//go:generate echo
+// Parse parses Kind
+func Parse(s string) Kind {
+	return defaultKind
+}
@@ -0,0 +1,8 @@
+package decls
+
+// List of Kind
+type List[T any] []T
+
+func (l List[T]) Len() int {
+	return len(l)
+}
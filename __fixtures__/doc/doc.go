@@ -0,0 +1,34 @@
+package doc
+
+import (
+	"strings"
+)
+
+// Builder builds strings. It wraps [strings.Builder].
+//
+// # Usage
+//
+// Create one by [NewBuilder], then call [Builder.Write]:
+//
+//	b := NewBuilder()
+//	b.Write("x")
+//
+// Options:
+//   - fast
+//   - safe
+//
+// See https://pkg.go.dev/strings for details.
+//
+//go:generate echo
+// +gengo:builder
+type Builder struct {
+	b strings.Builder
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) Write(s string) {
+	b.b.WriteString(s)
+}
@@ -0,0 +1,41 @@
+package doc
+
+import (
+	"go/token"
+	"strings"
+)
+
+// Pair of key and value
+type Pair[
+	// K key type
+	K comparable,
+	// V value type
+	V any,
+] struct {
+	// Key of pair
+	Key K
+	// Value of pair
+	Value V
+}
+
+// Located embeds token.Position
+type Located struct {
+	token.Position
+	// Pair of location
+	Pair Pair[string, int]
+}
+
+// Writer writes
+type Writer interface {
+	// Write writes s
+	Write(s string)
+}
+
+// Join joins values
+func Join(
+	// values to join
+	values []string,
+	sep string, // separator
+) string {
+	return strings.Join(values, sep)
+}
@@ -102,3 +102,56 @@ func FuncCallWithFuncLit() (a interface{}, b String) {
 func FuncWithCurryCall() interface{} {
 	return sub.CurryCall()()()
 }
+
+type StringAlias struct {
+	String
+}
+
+func (*StringAlias) PtrMethod() String {
+	return "ptr"
+}
+
+type List[T any] struct {
+	items []T
+}
+
+func (l *List[T]) Append(item T) *List[T] {
+	l.items = append(l.items, item)
+	return l
+}
+
+func (l List[T]) Len() int {
+	return len(l.items)
+}
+
+func NewList[T any](items ...T) *List[T] {
+	return &List[T]{items: items}
+}
+
+func First[T any](list []T) T {
+	var zero T
+	if len(list) > 0 {
+		return list[0]
+	}
+	return zero
+}
+
+func Map[T any, R any](list []T, fn func(T) R) []R {
+	results := make([]R, len(list))
+	for i := range list {
+		results[i] = fn(list[i])
+	}
+	return results
+}
+
+func FuncWithGenericCall() (a interface{}, b interface{}) {
+	return First[int]([]int{1}), First([]String{"1"})
+}
+
+func FuncWithGenericListCall() interface{} {
+	return Map[int, string]([]int{1}, func(i int) string { return "" })
+}
+
+func FuncWithGenericMethodCall() interface{} {
+	return NewList[string]().Append("1")
+}
@@ -0,0 +1,7 @@
+package matrix
+
+// Common exists under all configurations
+const Common = "common"
+
+// Version same in all configurations
+const Version = 1
@@ -0,0 +1,5 @@
+//go:build debug
+
+package matrix
+
+const Debug = true
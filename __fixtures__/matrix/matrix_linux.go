@@ -0,0 +1,6 @@
+package matrix
+
+// Separator of path list
+const Separator = ":"
+
+type Epoll struct{}
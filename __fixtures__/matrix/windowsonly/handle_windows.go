@@ -0,0 +1,4 @@
+package windowsonly
+
+// Handle of windows
+type Handle uintptr
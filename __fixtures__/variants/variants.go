@@ -0,0 +1,4 @@
+package variants
+
+// Value of production
+const Value = 1
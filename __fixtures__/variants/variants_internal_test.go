@@ -0,0 +1,4 @@
+package variants
+
+// InternalValue only exists in test variant
+const InternalValue = Value + 1
@@ -0,0 +1,8 @@
+package variants_test
+
+import (
+	"github.com/go-courier/packagesx/__fixtures__/variants"
+)
+
+// ExternalValue only exists in external test package
+const ExternalValue = variants.Value + 2
@@ -4,21 +4,42 @@ import (
 	"go/ast"
 	"go/token"
 	"sort"
-	"strings"
 )
 
 func NewCommentScanner(fileSet *token.FileSet, file *ast.File) *CommentScanner {
 	commentMap := ast.NewCommentMap(fileSet, file, file.Comments)
 
+	parents := map[ast.Node]ast.Node{}
+	stack := make([]ast.Node, 0)
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node == nil {
+			stack = stack[0 : len(stack)-1]
+			return true
+		}
+		if len(stack) > 0 {
+			parents[node] = stack[len(stack)-1]
+		}
+		stack = append(stack, node)
+		return true
+	})
+
 	return &CommentScanner{
+		fset:       fileSet,
 		file:       file,
+		parents:    parents,
 		CommentMap: commentMap,
 	}
 }
 
 type CommentScanner struct {
-	file       *ast.File
+	fset *token.FileSet
+	file *ast.File
+	// parents of nodes in file
+	parents    map[ast.Node]ast.Node
 	CommentMap ast.CommentMap
+	// DirectiveParser used by DirectivesOf
+	DirectiveParser DirectiveParser
 }
 
 func (scanner *CommentScanner) CommentsOf(targetNode ast.Node) string {
@@ -43,41 +64,14 @@ func (scanner *CommentScanner) CommentGroupListOf(targetNode ast.Node) (commentG
 		}
 
 		if len(commentGroupList) == 0 {
-			for node, comments := range scanner.CommentMap {
-				if genDecl, ok := node.(*ast.GenDecl); ok {
-					for _, spec := range genDecl.Specs {
-						if targetNode == spec {
-							commentGroupList = append(commentGroupList, comments...)
-						}
-					}
-				}
+			if genDecl := scanner.parentOf(targetNode, isGenDecl); genDecl != nil {
+				commentGroupList = append(commentGroupList, scanner.CommentMap[genDecl]...)
 			}
 		}
 	default:
 		// find nearest parent node which have comments
-		{
-			var deltaPos token.Pos
-			var parentNode ast.Node
-
-			deltaPos = -1
-
-			ast.Inspect(scanner.file, func(node ast.Node) bool {
-				switch node.(type) {
-				case *ast.Field, ast.Decl, ast.Spec, ast.Stmt:
-					if targetNode.Pos() >= node.Pos() && targetNode.End() <= node.End() {
-						nextDelta := targetNode.Pos() - node.Pos()
-						if deltaPos == -1 || (nextDelta <= deltaPos) {
-							deltaPos = nextDelta
-							parentNode = node
-						}
-					}
-				}
-				return true
-			})
-
-			if parentNode != nil {
-				commentGroupList = scanner.CommentGroupListOf(parentNode)
-			}
+		if parentNode := scanner.parentOf(targetNode, isCommentedNode); parentNode != nil {
+			commentGroupList = scanner.CommentGroupListOf(parentNode)
 		}
 	}
 
@@ -87,17 +81,143 @@ func (scanner *CommentScanner) CommentGroupListOf(targetNode ast.Node) (commentG
 	return
 }
 
-func StringifyCommentGroup(commentGroupList ...*ast.CommentGroup) (comments string) {
-	if len(commentGroupList) == 0 {
-		return ""
+// DocOf returns the leading doc comment of field, spec, decl or statement which contains targetNode.
+// Spec without doc uses the doc of its gen decl.
+func (scanner *CommentScanner) DocOf(targetNode ast.Node) *ast.CommentGroup {
+	if targetNode == nil {
+		return nil
+	}
+
+	node := scanner.commentedNodeOf(targetNode)
+
+	switch n := node.(type) {
+	case *ast.File:
+		return n.Doc
+	case *ast.Field:
+		return n.Doc
+	case *ast.FuncDecl:
+		return n.Doc
+	case *ast.GenDecl:
+		return n.Doc
+	case ast.Spec:
+		doc := (*ast.CommentGroup)(nil)
+		switch spec := n.(type) {
+		case *ast.ValueSpec:
+			doc = spec.Doc
+		case *ast.TypeSpec:
+			doc = spec.Doc
+		case *ast.ImportSpec:
+			doc = spec.Doc
+		}
+		if doc == nil {
+			if genDecl, ok := scanner.parentOf(n, isGenDecl).(*ast.GenDecl); ok {
+				doc = genDecl.Doc
+			}
+		}
+		return doc
+	case ast.Stmt:
+		// last comment group ends before statement
+		doc := (*ast.CommentGroup)(nil)
+		for _, commentGroup := range scanner.CommentMap[n] {
+			if commentGroup.End() <= n.Pos() && (doc == nil || commentGroup.Pos() > doc.Pos()) {
+				doc = commentGroup
+			}
+		}
+		return doc
+	}
+
+	return nil
+}
+
+// LineCommentOf returns the trailing line comment of field, spec or statement which contains targetNode.
+func (scanner *CommentScanner) LineCommentOf(targetNode ast.Node) *ast.CommentGroup {
+	if targetNode == nil {
+		return nil
+	}
+
+	node := scanner.commentedNodeOf(targetNode)
+
+	switch n := node.(type) {
+	case *ast.Field:
+		return n.Comment
+	case *ast.ValueSpec:
+		return n.Comment
+	case *ast.TypeSpec:
+		return n.Comment
+	case *ast.ImportSpec:
+		return n.Comment
+	case ast.Stmt:
+		// first comment group starts at the end line of statement
+		line := scanner.fset.Position(n.End()).Line
+		for _, commentGroup := range scanner.CommentMap[n] {
+			if commentGroup.Pos() >= n.End() && scanner.fset.Position(commentGroup.Pos()).Line == line {
+				return commentGroup
+			}
+		}
 	}
-	for _, commentGroup := range commentGroupList {
-		for _, line := range strings.Split(commentGroup.Text(), "\n") {
-			if strings.HasPrefix(line, "go:") {
-				continue
+
+	return nil
+}
+
+// commentedNodeOf returns targetNode when it could have comments, otherwise its nearest parent which could have comments
+func (scanner *CommentScanner) commentedNodeOf(targetNode ast.Node) ast.Node {
+	if _, ok := targetNode.(*ast.File); ok || isCommentedNode(targetNode) {
+		return targetNode
+	}
+	return scanner.parentOf(targetNode, isCommentedNode)
+}
+
+// parentOf returns the innermost node matched which contains targetNode
+func (scanner *CommentScanner) parentOf(targetNode ast.Node, match func(node ast.Node) bool) ast.Node {
+	if parent, ok := scanner.parents[targetNode]; ok {
+		for ; parent != nil; parent = scanner.parents[parent] {
+			if match(parent) {
+				return parent
 			}
-			comments = comments + "\n" + line
 		}
+		return nil
+	}
+
+	// targetNode not in file, like nodes created by callers, find by position
+	var deltaPos token.Pos
+	var parentNode ast.Node
+
+	deltaPos = -1
+
+	ast.Inspect(scanner.file, func(node ast.Node) bool {
+		if node == nil || node == targetNode || !match(node) {
+			return true
+		}
+		if targetNode.Pos() >= node.Pos() && targetNode.End() <= node.End() {
+			nextDelta := targetNode.Pos() - node.Pos()
+			if deltaPos == -1 || (nextDelta <= deltaPos) {
+				deltaPos = nextDelta
+				parentNode = node
+			}
+		}
+		return true
+	})
+
+	return parentNode
+}
+
+func isCommentedNode(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Field, ast.Decl, ast.Spec, ast.Stmt:
+		return true
+	}
+	return false
+}
+
+func isGenDecl(node ast.Node) bool {
+	_, ok := node.(*ast.GenDecl)
+	return ok
+}
+
+// StringifyCommentGroup returns text of comment groups without directives, see NewDoc
+func StringifyCommentGroup(commentGroupList ...*ast.CommentGroup) (comments string) {
+	if len(commentGroupList) == 0 {
+		return ""
 	}
-	return strings.TrimSpace(comments)
+	return NewDoc(commentGroupList...).Text
 }
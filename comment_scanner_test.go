@@ -24,3 +24,88 @@ func TestCommentScanner(t *testing.T) {
 		return true
 	})
 }
+
+func TestCommentScannerDocAndLineComment(t *testing.T) {
+	fset := token.NewFileSet()
+	contents, _ := ioutil.ReadFile("./__fixtures__/comments.go")
+	file, _ := parser.ParseFile(fset, "./__fixtures__/comments.go", contents, parser.ParseComments)
+
+	commentScanner := NewCommentScanner(fset, file)
+
+	identOf := func(name string) (ident *ast.Ident) {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if i, ok := node.(*ast.Ident); ok && i.Name == name && ident == nil {
+				ident = i
+			}
+			return ident == nil
+		})
+		return
+	}
+
+	textOf := func(commentGroup *ast.CommentGroup) string {
+		if commentGroup == nil {
+			return ""
+		}
+		return StringifyCommentGroup(commentGroup)
+	}
+
+	cases := []struct {
+		name        string
+		doc         string
+		lineComment string
+		merged      string
+	}{
+		{"A", "a", "A", "a\n\nA"},
+		{"Date", "type Date", "", "type Date"},
+		{"String", "field String", "", "field String"},
+		{"Test2", "type Test2", "", "type Test2"},
+		{"test3", "test3", "", "test3"},
+		{"Print", "func Print", "", "func Print"},
+		{"res", "Call", "", "Call"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ident := identOf(c.name)
+			NewWithT(t).Expect(textOf(commentScanner.DocOf(ident))).To(Equal(c.doc))
+			NewWithT(t).Expect(textOf(commentScanner.LineCommentOf(ident))).To(Equal(c.lineComment))
+			NewWithT(t).Expect(commentScanner.CommentsOf(ident)).To(Equal(c.merged))
+		})
+	}
+
+	t.Run("line comment of statement", func(t *testing.T) {
+		src := "package p\n\nfunc f() {\n\t// doc\n\ta := 1 // line\n\t_ = a\n}\n"
+		file, _ := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+		commentScanner := NewCommentScanner(fset, file)
+
+		stmt := file.Decls[0].(*ast.FuncDecl).Body.List[0]
+		NewWithT(t).Expect(textOf(commentScanner.DocOf(stmt))).To(Equal("doc"))
+		NewWithT(t).Expect(textOf(commentScanner.LineCommentOf(stmt))).To(Equal("line"))
+		NewWithT(t).Expect(commentScanner.CommentsOf(stmt)).To(Equal("doc\n\nline"))
+
+		NewWithT(t).Expect(commentScanner.LineCommentOf(file.Decls[0].(*ast.FuncDecl).Body.List[1])).To(BeNil())
+	})
+}
+
+func TestCommentScannerParentOf(t *testing.T) {
+	fset := token.NewFileSet()
+	contents, _ := ioutil.ReadFile("./__fixtures__/comments.go")
+	file, _ := parser.ParseFile(fset, "./__fixtures__/comments.go", contents, parser.ParseComments)
+
+	commentScanner := NewCommentScanner(fset, file)
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			// nodes not in file resolve by position
+			copied := &ast.Ident{NamePos: ident.NamePos, Name: ident.Name}
+
+			if parent := commentScanner.parentOf(ident, isCommentedNode); parent != nil {
+				NewWithT(t).Expect(commentScanner.parentOf(copied, isCommentedNode)).To(BeIdenticalTo(parent))
+			} else {
+				NewWithT(t).Expect(commentScanner.parentOf(copied, isCommentedNode)).To(BeNil())
+			}
+			NewWithT(t).Expect(commentScanner.CommentsOf(copied)).To(Equal(commentScanner.CommentsOf(ident)))
+		}
+		return true
+	})
+}
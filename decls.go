@@ -0,0 +1,222 @@
+package packagesx
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+)
+
+type DeclKind int
+
+const (
+	DeclUnknown DeclKind = iota
+	DeclConst
+	DeclVar
+	DeclType
+	DeclFunc
+	DeclMethod
+)
+
+func (k DeclKind) String() string {
+	switch k {
+	case DeclConst:
+		return "const"
+	case DeclVar:
+		return "var"
+	case DeclType:
+		return "type"
+	case DeclFunc:
+		return "func"
+	case DeclMethod:
+		return "method"
+	}
+	return "unknown"
+}
+
+// Decl is one declared name of package
+type Decl struct {
+	Kind DeclKind
+	// Decl is *ast.GenDecl or *ast.FuncDecl
+	Decl ast.Decl
+	// Spec is nil for funcs and methods
+	Spec   ast.Spec
+	Ident  *ast.Ident
+	Object types.Object
+	File   *ast.File
+	Doc    string
+}
+
+// Receiver returns type name of receiver for methods
+func (d *Decl) Receiver() string {
+	if funcDecl, ok := d.Decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil {
+		return receiverTypeName(funcDecl.Recv.List[0].Type)
+	}
+	return ""
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(e.X)
+	case *ast.ParenExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// DeclFilter filters Decls, zero value matches all.
+type DeclFilter struct {
+	Kinds []DeclKind
+	// Exported only
+	Exported bool
+	// Receiver matches methods of the type name
+	Receiver string
+	// Filename matches by base name or full path
+	Filename string
+	// Directive matches declarations with the named directive in doc comment, like go:generate or gengo:enum
+	Directive string
+	// Type matches consts and vars of the type
+	Type types.Type
+}
+
+func (f *DeclFilter) matchKind(kind DeclKind) bool {
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, k := range f.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Decls returns declarations of package matched filter, in source order.
+func (prog *Package) Decls(filter DeclFilter) []Decl {
+	if err := prog.u().ensureSyntax(prog.Package); err != nil {
+		return nil
+	}
+
+	checked := prog.u().syntaxOf(prog.Package)
+	parser := prog.u().directiveParserOf()
+
+	list := make([]Decl, 0)
+
+	for _, file := range checked.files {
+		if filter.Filename != "" {
+			filename := prog.Fset.File(file.Pos()).Name()
+			if filter.Filename != filename && filter.Filename != filepath.Base(filename) {
+				continue
+			}
+		}
+
+		scanner := prog.u().commentScannerOf(prog.Fset, file)
+
+		add := func(kind DeclKind, decl ast.Decl, spec ast.Spec, ident *ast.Ident, docs ...*ast.CommentGroup) {
+			if ident.Name == "_" || !filter.matchKind(kind) {
+				return
+			}
+			if filter.Exported && !ident.IsExported() {
+				return
+			}
+			if filter.Directive != "" && !hasDirective(parser, filter.Directive, docs...) {
+				return
+			}
+
+			d := Decl{
+				Kind:  kind,
+				Decl:  decl,
+				Spec:  spec,
+				Ident: ident,
+				File:  file,
+			}
+
+			if filter.Receiver != "" && d.Receiver() != filter.Receiver {
+				return
+			}
+
+			d.Object = prog.objectOfDecl(&d, checked.info)
+			if d.Object == nil {
+				return
+			}
+
+			if filter.Type != nil {
+				if kind != DeclConst && kind != DeclVar || !types.Identical(d.Object.Type(), filter.Type) {
+					return
+				}
+			}
+
+			d.Doc = scanner.CommentsOf(ident)
+
+			list = append(list, d)
+		}
+
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv != nil {
+					add(DeclMethod, decl, nil, decl.Name, decl.Doc)
+				} else {
+					add(DeclFunc, decl, nil, decl.Name, decl.Doc)
+				}
+			case *ast.GenDecl:
+				kind := DeclUnknown
+				switch decl.Tok {
+				case token.CONST:
+					kind = DeclConst
+				case token.VAR:
+					kind = DeclVar
+				case token.TYPE:
+					kind = DeclType
+				default:
+					continue
+				}
+
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							add(kind, decl, spec, name, decl.Doc, spec.Doc)
+						}
+					case *ast.TypeSpec:
+						add(kind, decl, spec, spec.Name, decl.Doc, spec.Doc)
+					}
+				}
+			}
+		}
+	}
+
+	return list
+}
+
+// objectOfDecl prefers objects of prog.Types,
+// since syntax of dependencies could be checked again from source.
+func (prog *Package) objectOfDecl(d *Decl, info *types.Info) types.Object {
+	if d.Kind == DeclMethod {
+		if method := prog.Method(d.Receiver(), d.Ident.Name); method != nil {
+			return method
+		}
+	} else if obj := prog.lookup(d.Ident.Name); obj != nil {
+		return obj
+	}
+	if info != nil {
+		return info.Defs[d.Ident]
+	}
+	return nil
+}
+
+func hasDirective(parser DirectiveParser, name string, docs ...*ast.CommentGroup) bool {
+	for _, d := range parser.ParseAll(docs...) {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
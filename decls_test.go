@@ -0,0 +1,82 @@
+package packagesx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPackageDecls(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, err := Load(filepath.Join(cwd, "./__fixtures__/decls"))
+	NewWithT(t).Expect(err).To(BeNil())
+
+	names := func(decls []Decl) []string {
+		list := make([]string, len(decls))
+		for i, d := range decls {
+			list[i] = d.Kind.String() + " " + d.Ident.Name
+			if r := d.Receiver(); r != "" {
+				list[i] = d.Kind.String() + " " + r + "." + d.Ident.Name
+			}
+		}
+		return list
+	}
+
+	t.Run("all in source order", func(t *testing.T) {
+		NewWithT(t).Expect(names(pkg.Decls(DeclFilter{}))).To(Equal([]string{
+			"type Kind",
+			"const KindCat",
+			"const KindDog",
+			"const kindUnknown",
+			"const Max",
+			"var defaultKind",
+			"method Kind.String",
+			"method Kind.set",
+			"func Parse",
+			"type List",
+			"method List.Len",
+		}))
+	})
+
+	t.Run("with object and doc", func(t *testing.T) {
+		decls := pkg.Decls(DeclFilter{Kinds: []DeclKind{DeclFunc}})
+		NewWithT(t).Expect(decls).To(HaveLen(1))
+		NewWithT(t).Expect(decls[0].Object).To(BeIdenticalTo(pkg.Func("Parse")))
+		NewWithT(t).Expect(decls[0].Doc).To(Equal("Parse parses Kind"))
+		NewWithT(t).Expect(decls[0].Decl).To(BeIdenticalTo(pkg.FuncDeclOf(pkg.Func("Parse"))))
+
+		methods := pkg.Decls(DeclFilter{Kinds: []DeclKind{DeclMethod}, Receiver: "List"})
+		NewWithT(t).Expect(methods).To(HaveLen(1))
+		NewWithT(t).Expect(methods[0].Object).To(BeIdenticalTo(pkg.Method("List", "Len")))
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		NewWithT(t).Expect(names(pkg.Decls(DeclFilter{Exported: true, Kinds: []DeclKind{DeclType, DeclFunc}}))).To(Equal([]string{
+			"type Kind",
+			"func Parse",
+			"type List",
+		}))
+
+		NewWithT(t).Expect(names(pkg.Decls(DeclFilter{Receiver: "Kind"}))).To(Equal([]string{
+			"method Kind.String",
+			"method Kind.set",
+		}))
+
+		NewWithT(t).Expect(names(pkg.Decls(DeclFilter{Filename: "list.go"}))).To(Equal([]string{
+			"type List",
+			"method List.Len",
+		}))
+
+		NewWithT(t).Expect(names(pkg.Decls(DeclFilter{Kinds: []DeclKind{DeclConst}, Type: pkg.TypeName("Kind").Type()}))).To(Equal([]string{
+			"const KindCat",
+			"const KindDog",
+			"const kindUnknown",
+		}))
+
+		NewWithT(t).Expect(names(pkg.Decls(DeclFilter{Directive: "gengo:enum"}))).To(Equal([]string{"type Kind"}))
+		NewWithT(t).Expect(names(pkg.Decls(DeclFilter{Directive: "go:generate"}))).To(Equal([]string{"func Parse"}))
+		NewWithT(t).Expect(pkg.Decls(DeclFilter{Directive: "gengo"})).To(BeEmpty())
+	})
+}
@@ -0,0 +1,192 @@
+package packagesx
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// Directive in comments, forms of
+//
+//	//go:generate go run ./gen    => {Name: "go:generate", Args: ["go", "run", "./gen"]}
+//	// +gengo:enum                => {Name: "gengo:enum"}
+//	// +k8s:openapi-gen=true      => {Name: "k8s:openapi-gen", Args: ["true"]}
+//	// @deprecated use New        => {Name: "deprecated", Args: ["use", "New"]}
+//
+// and lines start with Prefixes of DirectiveParser.
+type Directive struct {
+	Name string
+	Args []string
+	Pos  token.Pos
+}
+
+// DirectiveParser parses directives,
+// with Prefixes of custom directives, like "openapi:" for "// openapi:strfmt date-time"
+type DirectiveParser struct {
+	Prefixes []string
+}
+
+func (p DirectiveParser) hasPrefix(text string) bool {
+	for _, prefix := range p.Prefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDirective parses directive from line comment without custom prefixes,
+// returns false when c is not a directive
+func ParseDirective(c *ast.Comment) (Directive, bool) {
+	return DirectiveParser{}.Parse(c)
+}
+
+// Parse parses directive from line comment,
+// returns false when c is not a directive
+func (p DirectiveParser) Parse(c *ast.Comment) (Directive, bool) {
+	if !strings.HasPrefix(c.Text, "//") {
+		return Directive{}, false
+	}
+
+	line := c.Text[2:]
+	text := strings.TrimSpace(line)
+
+	switch {
+	case isDirectiveComment(c.Text):
+		// //go:generate, //line, //export
+	case strings.HasPrefix(text, "+"), strings.HasPrefix(text, "@"):
+		text = text[1:]
+	case p.hasPrefix(text):
+	default:
+		return Directive{}, false
+	}
+
+	name, args := text, ""
+	if i := strings.IndexAny(text, " \t"); i > 0 {
+		name, args = text[0:i], text[i+1:]
+	}
+
+	d := Directive{Pos: c.Pos()}
+
+	// +key=value
+	if i := strings.Index(name, "="); i > 0 {
+		d.Args = append(d.Args, name[i+1:])
+		name = name[0:i]
+	}
+
+	if !isDirectiveName(name) {
+		return Directive{}, false
+	}
+
+	d.Name = name
+	d.Args = append(d.Args, splitArgs(args)...)
+
+	return d, true
+}
+
+// isDirectiveName reports whether name starts with a letter,
+// followed by letters, digits or any of "_-.:/",
+// so list items like "// + item" are not directives.
+func isDirectiveName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || strings.ContainsRune("_-.:/", r)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// splitArgs splits args by spaces, double-quoted args are unquoted
+func splitArgs(s string) (args []string) {
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return args
+		}
+
+		if s[0] == '"' {
+			if quoted, err := strconv.QuotedPrefix(s); err == nil {
+				arg, _ := strconv.Unquote(quoted)
+				args = append(args, arg)
+				s = s[len(quoted):]
+				continue
+			}
+		}
+
+		i := strings.IndexAny(s, " \t")
+		if i < 0 {
+			i = len(s)
+		}
+		args = append(args, s[0:i])
+		s = s[i:]
+	}
+}
+
+// ParseDirectives parses all directives of comment groups without custom prefixes
+func ParseDirectives(commentGroupList ...*ast.CommentGroup) []Directive {
+	return DirectiveParser{}.ParseAll(commentGroupList...)
+}
+
+// ParseAll parses all directives of comment groups
+func (p DirectiveParser) ParseAll(commentGroupList ...*ast.CommentGroup) []Directive {
+	directives := make([]Directive, 0)
+	for _, commentGroup := range commentGroupList {
+		if commentGroup == nil {
+			continue
+		}
+		for _, c := range commentGroup.List {
+			if d, ok := p.Parse(c); ok {
+				directives = append(directives, d)
+			}
+		}
+	}
+	return directives
+}
+
+func (scanner *CommentScanner) DirectivesOf(targetNode ast.Node) []Directive {
+	return scanner.DirectiveParser.ParseAll(scanner.CommentGroupListOf(targetNode)...)
+}
+
+// SetDirectivePrefixes sets prefixes of custom directives for packages loaded together with prog.
+func (prog *Package) SetDirectivePrefixes(prefixes ...string) {
+	u := prog.u()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.directiveParser = DirectiveParser{Prefixes: append([]string{}, prefixes...)}
+}
+
+func (u *universe) directiveParserOf() DirectiveParser {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.directiveParser
+}
+
+// DirectivesOf returns directives in comments of the declaration of obj,
+// with prefixes set by SetDirectivePrefixes.
+func (prog *Package) DirectivesOf(obj types.Object) []Directive {
+	ident := prog.IdentOf(obj)
+	if ident == nil {
+		return nil
+	}
+
+	r := prog.u().lookupFile(ident.Pos())
+	if r == nil {
+		return nil
+	}
+
+	scanner := prog.u().commentScannerOf(prog.Fset, r.file)
+
+	return prog.u().directiveParserOf().ParseAll(scanner.CommentGroupListOf(ident)...)
+}
@@ -0,0 +1,94 @@
+package packagesx
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestParseDirective(t *testing.T) {
+	parser := DirectiveParser{Prefixes: []string{"openapi:"}}
+
+	cases := []struct {
+		text      string
+		directive *Directive
+	}{
+		{"//go:generate go run ./gen", &Directive{Name: "go:generate", Args: []string{"go", "run", "./gen"}}},
+		{`//go:generate sh -c "echo hi"`, &Directive{Name: "go:generate", Args: []string{"sh", "-c", "echo hi"}}},
+		{"//nolint:errcheck", &Directive{Name: "nolint:errcheck"}},
+		{"// go:embed x", nil},
+		{"// +gengo:enum", &Directive{Name: "gengo:enum"}},
+		{"// +k8s:openapi-gen=true", &Directive{Name: "k8s:openapi-gen", Args: []string{"true"}}},
+		{"// +tag=a b", &Directive{Name: "tag", Args: []string{"a", "b"}}},
+		{"// @deprecated use New", &Directive{Name: "deprecated", Args: []string{"use", "New"}}},
+		{"// openapi:strfmt date-time", &Directive{Name: "openapi:strfmt", Args: []string{"date-time"}}},
+		{"// swagger:strfmt date-time", nil},
+		{"// a comment", nil},
+		{"// @", nil},
+		{"// + item", nil},
+		{"//   + fast", nil},
+		{"// @ me", nil},
+		{"// +1", nil},
+		{"/* +gengo:enum */", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.text, func(t *testing.T) {
+			d, ok := parser.Parse(&ast.Comment{Slash: 1, Text: c.text})
+			if c.directive == nil {
+				NewWithT(t).Expect(ok).To(BeFalse())
+				return
+			}
+			NewWithT(t).Expect(ok).To(BeTrue())
+			c.directive.Pos = 1
+			NewWithT(t).Expect(d).To(Equal(*c.directive))
+		})
+	}
+
+	t.Run("without custom prefixes", func(t *testing.T) {
+		_, ok := ParseDirective(&ast.Comment{Slash: 1, Text: "// openapi:strfmt date-time"})
+		NewWithT(t).Expect(ok).To(BeFalse())
+	})
+}
+
+func TestPackageDirectivesOf(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, err := Load(filepath.Join(cwd, "./__fixtures__/doc"))
+	NewWithT(t).Expect(err).To(BeNil())
+
+	directives := pkg.DirectivesOf(pkg.TypeName("Builder"))
+	NewWithT(t).Expect(directives).To(HaveLen(2))
+	NewWithT(t).Expect(directives[0].Name).To(Equal("go:generate"))
+	NewWithT(t).Expect(directives[0].Args).To(Equal([]string{"echo"}))
+	NewWithT(t).Expect(pkg.Fset.Position(directives[0].Pos).Line).To(Equal(22))
+	NewWithT(t).Expect(directives[1].Name).To(Equal("gengo:builder"))
+
+	NewWithT(t).Expect(pkg.DirectivesOf(pkg.Func("NewBuilder"))).To(BeEmpty())
+}
+
+func TestPackageSetDirectivePrefixes(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/directive\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "date.go"), "package directive\n\n// Date\n// openapi:strfmt date\ntype Date string\n")
+
+	pkgs, err := LoadWithConfig(&packages.Config{Dir: dir}, ".")
+	NewWithT(t).Expect(err).To(BeNil())
+
+	pkg := pkgs[0]
+
+	NewWithT(t).Expect(pkg.DirectivesOf(pkg.TypeName("Date"))).To(BeEmpty())
+	NewWithT(t).Expect(pkg.Decls(DeclFilter{Directive: "openapi:strfmt"})).To(BeEmpty())
+
+	pkg.SetDirectivePrefixes("openapi:")
+
+	directives := pkg.DirectivesOf(pkg.TypeName("Date"))
+	NewWithT(t).Expect(directives).To(HaveLen(1))
+	NewWithT(t).Expect(directives[0].Name).To(Equal("openapi:strfmt"))
+	NewWithT(t).Expect(directives[0].Args).To(Equal([]string{"date"}))
+	NewWithT(t).Expect(pkg.Decls(DeclFilter{Directive: "openapi:strfmt"})).To(HaveLen(1))
+}
@@ -0,0 +1,262 @@
+package packagesx
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/doc/comment"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// Doc is the parsed doc comment, following the syntax of go/doc/comment.
+type Doc struct {
+	// Text of doc comment without directives
+	Text string
+	// Summary is the first sentence of Text
+	Summary string
+	// Blocks of Text
+	Blocks []comment.Block
+	// Directives are comment lines removed from Text, like //go:generate
+	Directives []Directive
+}
+
+func (d *Doc) String() string {
+	return d.Text
+}
+
+// NewDoc parses comment groups with the default parser of go/doc/comment.
+func NewDoc(commentGroupList ...*ast.CommentGroup) *Doc {
+	return newDoc(&comment.Parser{}, commentGroupList...)
+}
+
+func newDoc(parser *comment.Parser, commentGroupList ...*ast.CommentGroup) *Doc {
+	d := &Doc{}
+
+	text := ""
+
+	for _, commentGroup := range commentGroupList {
+		for _, c := range commentGroup.List {
+			if isDirectiveComment(c.Text) {
+				if directive, ok := ParseDirective(c); ok {
+					d.Directives = append(d.Directives, directive)
+				}
+			}
+		}
+
+		// directives removed by Text are kept in d.Directives,
+		// others like "// go:embed x" are ordinary comments
+		text = text + "\n" + commentGroup.Text()
+	}
+
+	d.Text = strings.TrimSpace(text)
+
+	if d.Text != "" {
+		d.Summary = new(doc.Package).Synopsis(d.Text)
+		d.Blocks = parser.Parse(d.Text).Content
+	}
+
+	return d
+}
+
+// isDirectiveComment reports whether comment is removed by ast.CommentGroup.Text.
+// "// go:embed x" with a space is an ordinary comment.
+func isDirectiveComment(text string) bool {
+	if !strings.HasPrefix(text, "//") {
+		return false
+	}
+	line := text[2:]
+	for _, prefix := range []string{"line ", "extern ", "export "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	// //[a-z0-9]+:[a-z0-9]
+	colon := strings.Index(line, ":")
+	if colon <= 0 || colon+1 >= len(line) {
+		return false
+	}
+	for i := 0; i <= colon+1; i++ {
+		if i == colon {
+			continue
+		}
+		b := line[i]
+		if !('a' <= b && b <= 'z' || '0' <= b && b <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Paragraphs returns plain text of all paragraphs
+func (d *Doc) Paragraphs() []string {
+	list := make([]string, 0)
+	for _, b := range d.Blocks {
+		if p, ok := b.(*comment.Paragraph); ok {
+			list = append(list, plainText(p.Text))
+		}
+	}
+	return list
+}
+
+func (d *Doc) Headings() []string {
+	list := make([]string, 0)
+	for _, b := range d.Blocks {
+		if h, ok := b.(*comment.Heading); ok {
+			list = append(list, plainText(h.Text))
+		}
+	}
+	return list
+}
+
+// CodeBlocks returns text of preformatted blocks
+func (d *Doc) CodeBlocks() []string {
+	list := make([]string, 0)
+	for _, b := range d.Blocks {
+		if c, ok := b.(*comment.Code); ok {
+			list = append(list, c.Text)
+		}
+	}
+	return list
+}
+
+func (d *Doc) Lists() []*comment.List {
+	list := make([]*comment.List, 0)
+	for _, b := range d.Blocks {
+		if l, ok := b.(*comment.List); ok {
+			list = append(list, l)
+		}
+	}
+	return list
+}
+
+// DocLinks returns links like [Name], [pkg.Name] or [*pkg.Type.Method] in all blocks
+func (d *Doc) DocLinks() []*comment.DocLink {
+	list := make([]*comment.DocLink, 0)
+
+	var walkText func(texts []comment.Text)
+	var walkBlocks func(blocks []comment.Block)
+
+	walkText = func(texts []comment.Text) {
+		for _, t := range texts {
+			switch t := t.(type) {
+			case *comment.DocLink:
+				list = append(list, t)
+			case *comment.Link:
+				walkText(t.Text)
+			}
+		}
+	}
+
+	walkBlocks = func(blocks []comment.Block) {
+		for _, b := range blocks {
+			switch b := b.(type) {
+			case *comment.Paragraph:
+				walkText(b.Text)
+			case *comment.Heading:
+				walkText(b.Text)
+			case *comment.List:
+				for _, item := range b.Items {
+					walkBlocks(item.Content)
+				}
+			}
+		}
+	}
+
+	walkBlocks(d.Blocks)
+
+	return list
+}
+
+func plainText(texts []comment.Text) string {
+	s := strings.Builder{}
+	for _, t := range texts {
+		switch t := t.(type) {
+		case comment.Plain:
+			s.WriteString(string(t))
+		case comment.Italic:
+			s.WriteString(string(t))
+		case *comment.Link:
+			s.WriteString(plainText(t.Text))
+		case *comment.DocLink:
+			s.WriteString(plainText(t.Text))
+		}
+	}
+	return s.String()
+}
+
+// DocOf returns the parsed doc of node,
+// doc links resolve by imports of the file and objects of the package which contain node.
+func (prog *Package) DocOf(node ast.Node) *Doc {
+	r := prog.u().lookupFile(node.Pos())
+	if r == nil {
+		return &Doc{}
+	}
+
+	scanner := prog.u().commentScannerOf(prog.Fset, r.file)
+
+	return newDoc(docParserOf(r.file, r.types), scanner.CommentGroupListOf(node)...)
+}
+
+// DocOfObject returns the parsed doc of the declaration of obj,
+// which could be a field, method, interface method, type parameter or function parameter,
+// declared in any package of AllPackages.
+// Fields and methods of instantiated types resolve to the declarations of generic types.
+func (prog *Package) DocOfObject(obj types.Object) *Doc {
+	if obj == nil {
+		return &Doc{}
+	}
+
+	ident := prog.IdentOf(originOf(obj))
+	if ident == nil {
+		return &Doc{}
+	}
+
+	return prog.DocOf(ident)
+}
+
+func docParserOf(file *ast.File, pkg *types.Package) *comment.Parser {
+	names := map[string]string{}
+	if pkg != nil {
+		for _, imported := range pkg.Imports() {
+			names[imported.Path()] = imported.Name()
+		}
+	}
+
+	imports := map[string]string{}
+
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+
+		name, ok := names[importPath]
+		if !ok {
+			name = importPath[strings.LastIndex(importPath, "/")+1:]
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		imports[name] = importPath
+	}
+
+	return &comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			importPath, ok := imports[name]
+			return importPath, ok
+		},
+		LookupSym: func(recv string, name string) bool {
+			if pkg == nil {
+				return false
+			}
+			if recv == "" {
+				return pkg.Scope().Lookup(name) != nil
+			}
+			typeName, ok := pkg.Scope().Lookup(recv).(*types.TypeName)
+			if !ok {
+				return false
+			}
+			obj, _, _ := types.LookupFieldOrMethod(typeName.Type(), true, pkg, name)
+			return obj != nil
+		},
+	}
+}
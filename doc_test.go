@@ -0,0 +1,116 @@
+package packagesx
+
+import (
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPackageDocOf(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, err := Load(filepath.Join(cwd, "./__fixtures__/doc"))
+	NewWithT(t).Expect(err).To(BeNil())
+
+	d := pkg.DocOf(pkg.IdentOf(pkg.TypeName("Builder")))
+
+	NewWithT(t).Expect(d.Summary).To(Equal("Builder builds strings."))
+	NewWithT(t).Expect(d.Paragraphs()).To(Equal([]string{
+		"Builder builds strings. It wraps strings.Builder.",
+		"Create one by NewBuilder, then call Builder.Write:",
+		"Options:",
+		"See https://pkg.go.dev/strings for details.",
+		"+gengo:builder",
+	}))
+	NewWithT(t).Expect(d.Headings()).To(Equal([]string{"Usage"}))
+	NewWithT(t).Expect(d.CodeBlocks()).To(Equal([]string{"b := NewBuilder()\nb.Write(\"x\")\n"}))
+	NewWithT(t).Expect(d.Lists()).To(HaveLen(1))
+	NewWithT(t).Expect(d.Lists()[0].Items).To(HaveLen(2))
+
+	links := d.DocLinks()
+	NewWithT(t).Expect(links).To(HaveLen(3))
+	NewWithT(t).Expect(links[0].ImportPath).To(Equal("strings"))
+	NewWithT(t).Expect(links[0].Name).To(Equal("Builder"))
+	NewWithT(t).Expect(links[1].ImportPath).To(Equal(""))
+	NewWithT(t).Expect(links[1].Name).To(Equal("NewBuilder"))
+	NewWithT(t).Expect(links[2].Recv).To(Equal("Builder"))
+	NewWithT(t).Expect(links[2].Name).To(Equal("Write"))
+
+	NewWithT(t).Expect(d.Directives).To(HaveLen(1))
+	NewWithT(t).Expect(d.Directives[0].Name).To(Equal("go:generate"))
+	NewWithT(t).Expect(d.Directives[0].Args).To(Equal([]string{"echo"}))
+
+	NewWithT(t).Expect(pkg.CommentsOf(pkg.IdentOf(pkg.TypeName("Builder")))).To(Equal(d.Text))
+
+	t.Run("without comments", func(t *testing.T) {
+		d := pkg.DocOf(pkg.IdentOf(pkg.Func("NewBuilder")))
+		NewWithT(t).Expect(d.Text).To(Equal(""))
+		NewWithT(t).Expect(d.Blocks).To(BeEmpty())
+	})
+
+	t.Run("StringifyCommentGroup", func(t *testing.T) {
+		NewWithT(t).Expect(StringifyCommentGroup(&ast.CommentGroup{
+			List: []*ast.Comment{{Text: "// a"}, {Text: "//go:generate echo"}, {Text: "// go:b"}},
+		}, &ast.CommentGroup{
+			List: []*ast.Comment{{Text: "// A"}},
+		})).To(Equal("a\ngo:b\n\nA"))
+
+		d := NewDoc(&ast.CommentGroup{
+			List: []*ast.Comment{{Text: "// a"}, {Text: "//go:generate echo"}, {Text: "// go:embed x"}},
+		})
+		NewWithT(t).Expect(d.Text).To(Equal("a\ngo:embed x"))
+		NewWithT(t).Expect(d.Directives).To(HaveLen(1))
+		NewWithT(t).Expect(d.Directives[0].Name).To(Equal("go:generate"))
+	})
+}
+
+func TestPackageDocOfObject(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, err := Load(filepath.Join(cwd, "./__fixtures__/doc"))
+	NewWithT(t).Expect(err).To(BeNil())
+
+	docOf := func(obj types.Object) string {
+		NewWithT(t).Expect(obj).NotTo(BeNil())
+		return pkg.DocOfObject(obj).Text
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		NewWithT(t).Expect(docOf(pkg.Field("Located", "Pair"))).To(Equal("Pair of location"))
+		NewWithT(t).Expect(docOf(pkg.Field("Pair", "Key"))).To(Equal("Key of pair"))
+	})
+
+	t.Run("fields of instantiated type", func(t *testing.T) {
+		pair := pkg.Field("Located", "Pair").Type()
+		value, _, _ := types.LookupFieldOrMethod(pair, true, pkg.Types, "Value")
+		NewWithT(t).Expect(value.Type().String()).To(Equal("int"))
+		NewWithT(t).Expect(docOf(value)).To(Equal("Value of pair"))
+	})
+
+	t.Run("promoted fields of other package", func(t *testing.T) {
+		filename := pkg.Field("Located", "Filename")
+		NewWithT(t).Expect(filename.Pkg().Path()).To(Equal("go/token"))
+		NewWithT(t).Expect(docOf(filename)).To(Equal("filename, if any"))
+	})
+
+	t.Run("methods", func(t *testing.T) {
+		NewWithT(t).Expect(docOf(pkg.Method("Writer", "Write"))).To(Equal("Write writes s"))
+		NewWithT(t).Expect(docOf(pkg.Method("Located", "String"))).To(ContainSubstring("String returns a string"))
+	})
+
+	t.Run("type params", func(t *testing.T) {
+		typeParams := TypeParamsOf(pkg.TypeName("Pair"))
+		NewWithT(t).Expect(docOf(typeParams.At(0).Obj())).To(Equal("K key type"))
+		NewWithT(t).Expect(docOf(typeParams.At(1).Obj())).To(Equal("V value type"))
+	})
+
+	t.Run("params", func(t *testing.T) {
+		params := pkg.Func("Join").Type().(*types.Signature).Params()
+		NewWithT(t).Expect(docOf(params.At(0))).To(Equal("values to join"))
+		NewWithT(t).Expect(docOf(params.At(1))).To(Equal("separator"))
+	})
+
+	NewWithT(t).Expect(pkg.DocOfObject(nil).Text).To(Equal(""))
+}
@@ -0,0 +1,115 @@
+package packagesx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type PackageError struct {
+	PkgPath string
+	Pos     string
+	Msg     string
+	Kind    packages.ErrorKind
+}
+
+func (e PackageError) Error() string {
+	pos := e.Pos
+	if pos == "" {
+		pos = "-"
+	}
+	return fmt.Sprintf("%s: %s: %s", e.PkgPath, pos, e.Msg)
+}
+
+// LoadError collects list, parse and type errors of loaded packages
+type LoadError struct {
+	Errors []PackageError
+}
+
+func (e *LoadError) Error() string {
+	buf := bytes.NewBufferString(fmt.Sprintf("%d error(s) while loading packages:", len(e.Errors)))
+	for _, err := range e.Errors {
+		buf.WriteString("\n\t")
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+func (e *LoadError) ErrorsOf(kind packages.ErrorKind) (list []PackageError) {
+	for _, err := range e.Errors {
+		if err.Kind == kind {
+			list = append(list, err)
+		}
+	}
+	return
+}
+
+// ErrorsOf returns *LoadError when any of pkgs contains errors or is ill-typed, otherwise nil.
+func ErrorsOf(pkgs ...*packages.Package) error {
+	loadErr := &LoadError{}
+
+	for _, pkg := range pkgs {
+		for _, err := range pkg.Errors {
+			loadErr.Errors = append(loadErr.Errors, PackageError{
+				PkgPath: pkg.PkgPath,
+				Pos:     err.Pos,
+				Msg:     err.Msg,
+				Kind:    err.Kind,
+			})
+		}
+	}
+
+	if len(loadErr.Errors) == 0 {
+		// ill-typed without any error means some dependency was broken but not loaded
+		for _, pkg := range pkgs {
+			if pkg.IllTyped {
+				loadErr.Errors = append(loadErr.Errors, PackageError{
+					PkgPath: pkg.PkgPath,
+					Msg:     "package or one of its dependencies is ill-typed",
+					Kind:    packages.TypeError,
+				})
+			}
+		}
+	}
+
+	if len(loadErr.Errors) == 0 {
+		return nil
+	}
+
+	return loadErr
+}
+
+// ErrNoPackages is returned when patterns match no package
+type ErrNoPackages struct {
+	Patterns []string
+	Dir      string
+	// Err holds list errors reported by the build system for patterns, if any
+	Err error
+}
+
+func (e *ErrNoPackages) Error() string {
+	msg := fmt.Sprintf("no packages matched %s in %s", strings.Join(e.Patterns, " "), e.Dir)
+	if e.Err != nil {
+		msg = msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ErrNoPackages) Unwrap() error {
+	return e.Err
+}
+
+// ErrMultiplePackages is returned by LoadOne
+// when pattern matches more than one package.
+type ErrMultiplePackages struct {
+	Pattern string
+	// Picked is the ID of the package which Load picks
+	Picked  string
+	Matched []string
+}
+
+func (e *ErrMultiplePackages) Error() string {
+	return fmt.Sprintf("pattern %s matched %d packages (%s), picked %s", e.Pattern, len(e.Matched), strings.Join(e.Matched, ", "), e.Picked)
+}
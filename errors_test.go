@@ -0,0 +1,90 @@
+package packagesx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestLoadError(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	t.Run("not strict", func(t *testing.T) {
+		pkg, err := Load(filepath.Join(cwd, "./__fixtures__/broken"))
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(pkg.IllTyped).To(BeTrue())
+
+		loadErr := &LoadError{}
+		NewWithT(t).Expect(errors.As(pkg.Err(), &loadErr)).To(BeTrue())
+
+		typeErrors := loadErr.ErrorsOf(packages.TypeError)
+		NewWithT(t).Expect(typeErrors).To(HaveLen(2))
+
+		for _, e := range typeErrors {
+			NewWithT(t).Expect(e.PkgPath).To(Equal("github.com/go-courier/packagesx/__fixtures__/broken"))
+			NewWithT(t).Expect(e.Pos).To(ContainSubstring("broken.go:"))
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		pkg, err := LoadStrict(filepath.Join(cwd, "./__fixtures__/broken"))
+		NewWithT(t).Expect(pkg).To(BeNil())
+
+		loadErr := &LoadError{}
+		NewWithT(t).Expect(errors.As(err, &loadErr)).To(BeTrue())
+		NewWithT(t).Expect(err.Error()).To(ContainSubstring("broken.go:3"))
+	})
+
+	t.Run("strict without errors", func(t *testing.T) {
+		pkg, err := LoadStrict(filepath.Join(cwd, "./__fixtures__/sub"))
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(pkg.Err()).To(BeNil())
+	})
+}
+
+func TestErrNoPackages(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	for _, pattern := range []string{"./__fixtures__/nope", "./__fixtures__/nope/...", "./__fixtures__/..."} {
+		t.Run(pattern, func(t *testing.T) {
+			pkgs, err := LoadWithConfig(&packages.Config{Dir: cwd}, pattern)
+			NewWithT(t).Expect(pkgs).To(BeNil())
+
+			errNoPackages := &ErrNoPackages{}
+			NewWithT(t).Expect(errors.As(err, &errNoPackages)).To(BeTrue())
+			NewWithT(t).Expect(errNoPackages.Patterns).To(Equal([]string{pattern}))
+			NewWithT(t).Expect(errNoPackages.Dir).To(Equal(cwd))
+		})
+	}
+
+	t.Run("Load", func(t *testing.T) {
+		pkg, err := Load("./__fixtures__/nope")
+		NewWithT(t).Expect(pkg).To(BeNil())
+		NewWithT(t).Expect(err.Error()).To(ContainSubstring("no packages matched ./__fixtures__/nope in " + cwd))
+	})
+}
+
+func TestErrMultiplePackages(t *testing.T) {
+	pkg, err := Load("unicode/...")
+	NewWithT(t).Expect(err).To(BeNil())
+	NewWithT(t).Expect(pkg.Matched()[0]).To(Equal(pkg.ID))
+	NewWithT(t).Expect(len(pkg.Matched()) > 1).To(BeTrue())
+
+	t.Run("LoadOne", func(t *testing.T) {
+		pkg, err := LoadOne("unicode/...")
+		NewWithT(t).Expect(pkg).To(BeNil())
+
+		errMultiplePackages := &ErrMultiplePackages{}
+		NewWithT(t).Expect(errors.As(err, &errMultiplePackages)).To(BeTrue())
+		NewWithT(t).Expect(errMultiplePackages.Picked).To(Equal("unicode"))
+		NewWithT(t).Expect(len(errMultiplePackages.Matched) > 1).To(BeTrue())
+
+		pkg, err = LoadOne("unicode")
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(pkg.Matched()).To(Equal([]string{"unicode"}))
+	})
+}
@@ -0,0 +1,329 @@
+package packagesx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// factsVersion should be bumped when struct of Facts changed
+const factsVersion = "2"
+
+// Facts derived from a package, which could be cached on disk between runs
+type Facts struct {
+	PkgPath string        `json:"pkgPath"`
+	Symbols []SymbolFacts `json:"symbols"`
+}
+
+func (facts *Facts) Symbol(name string) *SymbolFacts {
+	for i := range facts.Symbols {
+		if facts.Symbols[i].Name == name {
+			return &facts.Symbols[i]
+		}
+	}
+	return nil
+}
+
+type SymbolFacts struct {
+	// Name of package-level object, or Type.Method for methods
+	Name string `json:"name"`
+	// Kind of object: const, type, var, func or method
+	Kind string `json:"kind"`
+	Type string `json:"type"`
+	// Value of const
+	Value string `json:"value,omitempty"`
+	// Pos as "file.go:line:column", file is relative to package dir
+	Pos string `json:"pos"`
+	Doc string `json:"doc,omitempty"`
+	// Results of funcs and methods, formatted as type or type(value)
+	Results [][]string `json:"results,omitempty"`
+}
+
+// SetCacheDir enables caching Facts in dir for packages loaded together with prog.
+func (prog *Package) SetCacheDir(dir string) {
+	u := prog.u()
+
+	u.factsMu.Lock()
+	defer u.factsMu.Unlock()
+
+	u.cacheDir = dir
+}
+
+// Facts returns facts of the package.
+// When cache dir set, facts are served from cache until any file of the package or its dependencies changed.
+func (prog *Package) Facts() (*Facts, error) {
+	u := prog.u()
+
+	// overlay is guarded by syntaxMu, which is locked before factsMu like reload did
+	u.syntaxMu.Lock()
+	u.factsMu.Lock()
+	cacheDir := u.cacheDir
+	key, err := u.factsKeyOf(prog.Package)
+	u.factsMu.Unlock()
+	u.syntaxMu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	filename := ""
+
+	if cacheDir != "" {
+		filename = filepath.Join(cacheDir, key[0:2], key+".json")
+
+		if data, err := os.ReadFile(filename); err == nil {
+			facts := &Facts{}
+			if err := json.Unmarshal(data, facts); err == nil {
+				return facts, nil
+			}
+		}
+	}
+
+	facts, err := prog.analyzeFacts()
+	if err != nil {
+		return nil, err
+	}
+
+	if filename != "" {
+		if err := writeFileAtomic(filename, facts); err != nil {
+			return nil, err
+		}
+	}
+
+	return facts, nil
+}
+
+func (prog *Package) analyzeFacts() (*Facts, error) {
+	if prog.Types == nil {
+		return nil, fmt.Errorf("package %s is loaded without types", prog.ID)
+	}
+
+	if err := prog.u().ensureSyntax(prog.Package); err != nil {
+		return nil, err
+	}
+
+	facts := &Facts{
+		PkgPath: prog.PkgPath,
+		Symbols: prog.symbolFacts(true),
+	}
+
+	return facts, nil
+}
+
+// symbolFacts lists facts of package-level objects and methods in order of names,
+// results of funcs will be analyzed only when withResults.
+func (prog *Package) symbolFacts(withResults bool) []SymbolFacts {
+	list := make([]SymbolFacts, 0)
+
+	scope := prog.Types.Scope()
+
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+
+		list = append(list, prog.symbolFactsOf(name, obj, withResults))
+
+		if typeName, ok := obj.(*types.TypeName); ok && !typeName.IsAlias() {
+			if named, ok := typeName.Type().(*types.Named); ok {
+				methods := make([]*types.Func, 0, named.NumMethods())
+				for i := 0; i < named.NumMethods(); i++ {
+					methods = append(methods, named.Method(i))
+				}
+				sort.Slice(methods, func(i, j int) bool {
+					return methods[i].Name() < methods[j].Name()
+				})
+				for _, method := range methods {
+					list = append(list, prog.symbolFactsOf(name+"."+method.Name(), method, withResults))
+				}
+			}
+		}
+	}
+
+	return list
+}
+
+func (prog *Package) symbolFactsOf(name string, obj types.Object, withResults bool) SymbolFacts {
+	s := SymbolFacts{
+		Name: name,
+		Type: types.TypeString(obj.Type(), types.RelativeTo(prog.Types)),
+	}
+
+	switch o := obj.(type) {
+	case *types.Const:
+		s.Kind = "const"
+		s.Value = o.Val().ExactString()
+	case *types.TypeName:
+		s.Kind = "type"
+	case *types.Var:
+		s.Kind = "var"
+	case *types.Func:
+		s.Kind = "func"
+		if o.Type().(*types.Signature).Recv() != nil {
+			s.Kind = "method"
+		}
+		if withResults {
+			s.Results = prog.formatFuncResults(o)
+		}
+	}
+
+	position := prog.Fset.Position(prog.sourcePosOf(obj))
+	if rel, err := filepath.Rel(prog.Dir, position.Filename); err == nil {
+		position.Filename = rel
+	}
+	s.Pos = position.String()
+
+	if ident := prog.IdentOf(obj); ident != nil {
+		s.Doc = prog.CommentsOf(ident)
+	}
+
+	return s
+}
+
+func (prog *Package) formatFuncResults(typeFunc *types.Func) [][]string {
+	results, n := prog.FuncResultsOf(typeFunc)
+	if n == 0 {
+		return nil
+	}
+
+	list := make([][]string, n)
+	for i := range list {
+		for _, tv := range results[i] {
+			if tv.Type == nil {
+				continue
+			}
+			if tv.Value == nil {
+				list[i] = append(list[i], tv.Type.String())
+				continue
+			}
+			list[i] = append(list[i], fmt.Sprintf("%s(%s)", tv.Type, tv.Value))
+		}
+	}
+	return list
+}
+
+// factsKeyOf hashes go env, build flags, files of pkg and keys of its dependencies,
+// contents of files in overlay are hashed instead of files on disk.
+// Standard library is keyed by go env and build flags only.
+func (u *universe) factsKeyOf(pkg *packages.Package) (string, error) {
+	if key, ok := u.factsKeys[pkg]; ok {
+		return key, nil
+	}
+
+	build, err := u.factsBuildOf()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+
+	_, _ = fmt.Fprintf(h, "packagesx facts %s\n%s\n%s\n", factsVersion, build, pkg.ID)
+
+	if PackageKindOf(pkg) != PackageKindStdlib {
+		files := pkg.CompiledGoFiles
+		if len(files) == 0 {
+			files = pkg.GoFiles
+		}
+
+		for _, filename := range files {
+			fileHash, err := u.hashFile(filename)
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(h, "%s %s\n", filepath.Base(filename), fileHash)
+		}
+
+		for _, importPath := range sortedImportPaths(pkg) {
+			key, err := u.factsKeyOf(pkg.Imports[importPath])
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(h, "import %s %s\n", importPath, key)
+		}
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	u.factsKeys[pkg] = key
+	return key, nil
+}
+
+// factsBuildOf returns go env of the toolchain which packages loaded by, and build flags of config,
+// since files and sizes of packages, standard library included, differ by them.
+func (u *universe) factsBuildOf() (string, error) {
+	if u.factsBuild != "" {
+		return u.factsBuild, nil
+	}
+
+	c := packages.Config{}
+	if u.config != nil {
+		c = *u.config
+	}
+
+	cmd := exec.Command("go", "env", "-json", "GOVERSION", "GOOS", "GOARCH", "CGO_ENABLED", "GOFLAGS")
+	cmd.Dir = c.Dir
+	cmd.Env = c.Env
+
+	env, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go env: %w", err)
+	}
+
+	u.factsBuild = fmt.Sprintf("%s\nflags %q", strings.TrimSpace(string(env)), c.BuildFlags)
+	return u.factsBuild, nil
+}
+
+func (u *universe) hashFile(filename string) (string, error) {
+	if content, ok := u.overlayOf(filename); ok {
+		sum := sha256.Sum256(content)
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeFileAtomic(filename string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+strings.TrimSuffix(filepath.Base(filename), ".json")+"-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,147 @@
+package packagesx
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestFacts(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	facts, err := pkg.Facts()
+	NewWithT(t).Expect(err).To(BeNil())
+	NewWithT(t).Expect(facts.PkgPath).To(Equal("github.com/go-courier/packagesx/__fixtures__"))
+
+	NewWithT(t).Expect(*facts.Symbol("Print")).To(Equal(SymbolFacts{
+		Name:    "Print",
+		Kind:    "func",
+		Type:    "func(a string, b string) string",
+		Pos:     "comments.go:75:6",
+		Doc:     "func Print",
+		Results: [][]string{{"string"}},
+	}))
+
+	NewWithT(t).Expect(facts.Symbol("String.Method").Kind).To(Equal("method"))
+	NewWithT(t).Expect(facts.Symbol("FuncSingleNamedReturnByAssign").Results).To(Equal([][]string{
+		{`untyped string("1")`},
+		{`github.com/go-courier/packagesx/__fixtures__.String("2")`},
+	}))
+	NewWithT(t).Expect(facts.Symbol("A").Kind).To(Equal("const"))
+	NewWithT(t).Expect(facts.Symbol("Test").Kind).To(Equal("type"))
+	NewWithT(t).Expect(facts.Symbol("test").Kind).To(Equal("var"))
+}
+
+func TestFactsCache(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/facts\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "dep", "dep.go"), "package dep\n\n// Value of dep\nconst Value = 1\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package facts\n\nimport \"example.com/facts/dep\"\n\n// Value doc\nconst Value = dep.Value\n")
+
+	load := func() *Package {
+		pkgs, err := LoadWithConfig(&packages.Config{Dir: dir}, ".")
+		NewWithT(t).Expect(err).To(BeNil())
+		pkgs[0].SetCacheDir(cacheDir)
+		return pkgs[0]
+	}
+
+	facts, err := load().Facts()
+	NewWithT(t).Expect(err).To(BeNil())
+	NewWithT(t).Expect(facts.Symbol("Value").Doc).To(Equal("Value doc"))
+
+	cached, _ := filepath.Glob(filepath.Join(cacheDir, "*", "*.json"))
+	NewWithT(t).Expect(cached).To(HaveLen(1))
+
+	t.Run("served from cache when unchanged", func(t *testing.T) {
+		facts.Symbol("Value").Doc = "from cache"
+		data, _ := json.Marshal(facts)
+		writeFile(t, cached[0], string(data))
+
+		facts, err := load().Facts()
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(facts.Symbol("Value").Doc).To(Equal("from cache"))
+	})
+
+	t.Run("invalidated when file changed", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "main.go"), "package facts\n\nimport \"example.com/facts/dep\"\n\n// Value changed\nconst Value = dep.Value\n")
+
+		facts, err := load().Facts()
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(facts.Symbol("Value").Doc).To(Equal("Value changed"))
+	})
+
+	t.Run("invalidated when dependency changed", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "dep", "dep.go"), "package dep\n\n// Value of dep\nconst Value = \"1\"\n")
+
+		facts, err := load().Facts()
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(facts.Symbol("Value").Type).To(Equal("untyped string"))
+	})
+
+	cached, _ = filepath.Glob(filepath.Join(cacheDir, "*", "*.json"))
+	NewWithT(t).Expect(cached).To(HaveLen(3))
+
+	t.Run("invalidated when reloaded", func(t *testing.T) {
+		pkg := load()
+
+		facts, err := pkg.Facts()
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(facts.Symbol("Value").Value).To(Equal(`"1"`))
+
+		NewWithT(t).Expect(pkg.Reload(map[string][]byte{
+			filepath.Join(dir, "dep", "dep.go"): []byte("package dep\n\n// Value of dep\nconst Value = 2\n"),
+			filepath.Join(dir, "main.go"):       []byte("package facts\n\nimport \"example.com/facts/dep\"\n\n// Value reloaded\nconst Value = dep.Value\n"),
+		})).To(Succeed())
+
+		facts, err = pkg.Facts()
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(facts.Symbol("Value").Value).To(Equal("2"))
+		NewWithT(t).Expect(facts.Symbol("Value").Doc).To(Equal("Value reloaded"))
+	})
+}
+
+func TestFactsKeyOfBuild(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/facts\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package facts\n\nimport \"os\"\n\nconst Separator = os.PathSeparator\n")
+
+	keyOf := func(cfg *packages.Config, importPath string) string {
+		cfg.Dir = dir
+		pkgs, err := LoadWithConfig(cfg, ".")
+		NewWithT(t).Expect(err).To(BeNil())
+
+		u := pkgs[0].u()
+		u.factsMu.Lock()
+		defer u.factsMu.Unlock()
+
+		pkg := pkgs[0].Package
+		if importPath != "" {
+			pkg = pkg.Imports[importPath]
+		}
+
+		key, err := u.factsKeyOf(pkg)
+		NewWithT(t).Expect(err).To(BeNil())
+		return key
+	}
+
+	linux := keyOf(&packages.Config{Env: append(os.Environ(), "GOOS=linux", "GOARCH=amd64")}, "os")
+	windows := keyOf(&packages.Config{Env: append(os.Environ(), "GOOS=windows", "GOARCH=amd64")}, "os")
+
+	NewWithT(t).Expect(linux).NotTo(Equal(windows))
+	NewWithT(t).Expect(keyOf(&packages.Config{Env: append(os.Environ(), "GOOS=linux", "GOARCH=amd64")}, "os")).To(Equal(linux))
+
+	NewWithT(t).Expect(keyOf(&packages.Config{}, "")).NotTo(Equal(keyOf(&packages.Config{BuildFlags: []string{"-tags=debug"}}, "")))
+}
+
+func writeFile(t *testing.T, filename string, content string) {
+	NewWithT(t).Expect(os.MkdirAll(filepath.Dir(filename), os.ModePerm)).To(Succeed())
+	NewWithT(t).Expect(os.WriteFile(filename, []byte(content), 0644)).To(Succeed())
+}
@@ -0,0 +1,164 @@
+package packagesx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+)
+
+// TypeParamsOf returns type parameters of generic type or func,
+// for methods, returns type parameters of receiver.
+func TypeParamsOf(obj types.Object) *types.TypeParamList {
+	switch o := obj.(type) {
+	case *types.TypeName:
+		switch t := o.Type().(type) {
+		case *types.Named:
+			return t.TypeParams()
+		case *types.Alias:
+			return t.TypeParams()
+		}
+	case *types.Func:
+		sig := o.Type().(*types.Signature)
+		if sig.RecvTypeParams().Len() > 0 {
+			return sig.RecvTypeParams()
+		}
+		return sig.TypeParams()
+	}
+	return nil
+}
+
+// Instance is an instantiation of generic type or func recorded in TypesInfo.Instances
+type Instance struct {
+	Ident *ast.Ident
+	types.Instance
+}
+
+// InstancesOf returns instantiations of generic type or func obj,
+// in packages of AllPackages with syntax loaded, in order of position.
+func (prog *Package) InstancesOf(obj types.Object) []Instance {
+	if obj == nil {
+		return nil
+	}
+
+	origin := originOf(obj)
+
+	list := make([]Instance, 0)
+
+	for _, pkg := range prog.AllPackages {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+
+		for ident, instance := range pkg.TypesInfo.Instances {
+			used := pkg.TypesInfo.Uses[ident]
+			if used == nil || !prog.sameObject(originOf(used), origin) {
+				continue
+			}
+			list = append(list, Instance{Ident: ident, Instance: instance})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Ident.Pos() < list[j].Ident.Pos()
+	})
+
+	return list
+}
+
+func originOf(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
+// sameObject compares objects by package, name, file and line,
+// since objects of dependencies could be loaded from both export data and source,
+// and objects loaded from export data keep file and line only.
+func (prog *Package) sameObject(a types.Object, b types.Object) bool {
+	if a == b {
+		return true
+	}
+	if a.Pkg() == nil || b.Pkg() == nil {
+		return false
+	}
+	if a.Name() != b.Name() || a.Pkg().Path() != b.Pkg().Path() {
+		return false
+	}
+	positionA, positionB := prog.Fset.Position(a.Pos()), prog.Fset.Position(b.Pos())
+	return positionA.IsValid() && positionA.Filename == positionB.Filename && positionA.Line == positionB.Line
+}
+
+// IdentChainOfCallFunc works like GetIdentChainOfCallFunc,
+// but index expressions are resolved as instantiations by the info which expr checked with,
+// like f[T](x).
+func (prog *Package) IdentChainOfCallFunc(expr ast.Expr) []*ast.Ident {
+	info := prog.PkgInfoOf(expr)
+	if info == nil {
+		return GetIdentChainOfCallFunc(expr)
+	}
+	return identChainOfCallFunc(expr, func(index ast.Expr) bool {
+		return info.Types[index].IsType()
+	})
+}
+
+// FuncResultsOfInstance works like FuncResultsOf,
+// but resolves results of generic func or method of generic type with typeArgs.
+func (prog *Package) FuncResultsOfInstance(typeFunc *types.Func, typeArgs ...types.Type) (Results, int, error) {
+	if typeFunc == nil {
+		return nil, 0, nil
+	}
+
+	signature, err := instantiateFunc(typeFunc, typeArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	funcDecl := prog.FuncDeclOf(typeFunc.Origin())
+	if funcDecl == nil {
+		return nil, 0, nil
+	}
+
+	results, n := prog.FuncResultsOfSignature(signature, funcDecl.Body, funcDecl.Type)
+	return results, n, nil
+}
+
+func instantiateFunc(typeFunc *types.Func, typeArgs ...types.Type) (*types.Signature, error) {
+	signature := typeFunc.Type().(*types.Signature)
+
+	if signature.RecvTypeParams().Len() > 0 {
+		named, ok := Deref(signature.Recv().Type()).(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("invalid receiver of %s", typeFunc.FullName())
+		}
+
+		instance, err := types.Instantiate(nil, named.Origin(), typeArgs, true)
+		if err != nil {
+			return nil, err
+		}
+
+		method, _, _ := types.LookupFieldOrMethod(instance, true, typeFunc.Pkg(), typeFunc.Name())
+		if method == nil {
+			return nil, fmt.Errorf("method %s not found of %s", typeFunc.Name(), instance)
+		}
+		return method.Type().(*types.Signature), nil
+	}
+
+	if signature.TypeParams().Len() > 0 {
+		instance, err := types.Instantiate(nil, signature, typeArgs, true)
+		if err != nil {
+			return nil, err
+		}
+		return instance.(*types.Signature), nil
+	}
+
+	if len(typeArgs) > 0 {
+		return nil, fmt.Errorf("%s is not generic", typeFunc.FullName())
+	}
+
+	return signature, nil
+}
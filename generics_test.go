@@ -0,0 +1,81 @@
+package packagesx
+
+import (
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerics(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	t.Run("type params", func(t *testing.T) {
+		list := pkg.TypeName("List")
+		NewWithT(t).Expect(list).NotTo(BeNil())
+
+		typeParams := TypeParamsOf(list)
+		NewWithT(t).Expect(typeParams.Len()).To(Equal(1))
+		NewWithT(t).Expect(typeParams.At(0).Obj().Name()).To(Equal("T"))
+		NewWithT(t).Expect(typeParams.At(0).Constraint().String()).To(Equal("any"))
+
+		NewWithT(t).Expect(TypeParamsOf(pkg.Method("List", "Append")).Len()).To(Equal(1))
+		NewWithT(t).Expect(TypeParamsOf(pkg.Func("Map")).Len()).To(Equal(2))
+		NewWithT(t).Expect(TypeParamsOf(pkg.Func("Print")).Len()).To(Equal(0))
+	})
+
+	t.Run("ident chain of call func", func(t *testing.T) {
+		chains := make([][]string, 0)
+
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(node ast.Node) bool {
+				if callExpr, ok := node.(*ast.CallExpr); ok {
+					if _, ok := callExpr.Fun.(*ast.IndexExpr); ok {
+						names := make([]string, 0)
+						for _, ident := range pkg.IdentChainOfCallFunc(callExpr) {
+							names = append(names, ident.Name)
+						}
+						chains = append(chains, names)
+					}
+				}
+				return true
+			})
+		}
+
+		NewWithT(t).Expect(chains).To(ContainElement([]string{"First"}))
+		NewWithT(t).Expect(chains).To(ContainElement([]string{"NewList"}))
+	})
+
+	t.Run("instances", func(t *testing.T) {
+		instances := pkg.InstancesOf(pkg.Func("First"))
+		NewWithT(t).Expect(instances).To(HaveLen(2))
+		NewWithT(t).Expect(instances[0].TypeArgs.At(0).String()).To(Equal("int"))
+		NewWithT(t).Expect(instances[0].Type.String()).To(Equal("func(list []int) int"))
+		NewWithT(t).Expect(instances[1].TypeArgs.At(0).String()).To(Equal("github.com/go-courier/packagesx/__fixtures__.String"))
+
+		lists := pkg.InstancesOf(pkg.TypeName("List"))
+		NewWithT(t).Expect(lists).NotTo(BeEmpty())
+	})
+
+	t.Run("results of instance", func(t *testing.T) {
+		values, n := pkg.FuncResultsOf(pkg.Func("First"))
+		NewWithT(t).Expect(n).To(Equal(1))
+		NewWithT(t).Expect(printValues(pkg.Fset, values)).To(Equal([][]string{{"T", "T"}}))
+
+		values, n, err := pkg.FuncResultsOfInstance(pkg.Func("First"), types.Typ[types.String])
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(n).To(Equal(1))
+		NewWithT(t).Expect(printValues(pkg.Fset, values)).To(Equal([][]string{{"string", "string"}}))
+
+		values, _, err = pkg.FuncResultsOfInstance(pkg.Method("List", "Append"), types.Typ[types.Int])
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(printValues(pkg.Fset, values)).To(Equal([][]string{{"*github.com/go-courier/packagesx/__fixtures__.List[int]"}}))
+
+		_, _, err = pkg.FuncResultsOfInstance(pkg.Func("Print"), types.Typ[types.Int])
+		NewWithT(t).Expect(err).NotTo(BeNil())
+	})
+}
@@ -0,0 +1,349 @@
+package packagesx
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type PackageKind int
+
+const (
+	PackageKindUnknown PackageKind = iota
+	// PackageKindStdlib for packages of the standard library
+	PackageKindStdlib
+	// PackageKindModule for packages of main modules
+	PackageKindModule
+	// PackageKindThirdParty for packages of other modules
+	PackageKindThirdParty
+)
+
+func (k PackageKind) String() string {
+	switch k {
+	case PackageKindStdlib:
+		return "stdlib"
+	case PackageKindModule:
+		return "module"
+	case PackageKindThirdParty:
+		return "third-party"
+	}
+	return "unknown"
+}
+
+func PackageKindOf(pkg *packages.Package) PackageKind {
+	if pkg.Module != nil {
+		if pkg.Module.Main {
+			return PackageKindModule
+		}
+		return PackageKindThirdParty
+	}
+
+	pkgPath := pkg.PkgPath
+	if pkgPath == "" {
+		pkgPath = pkg.ID
+	}
+
+	// import path of std starts without domain
+	if !strings.Contains(strings.Split(pkgPath, "/")[0], ".") {
+		return PackageKindStdlib
+	}
+
+	return PackageKindThirdParty
+}
+
+// ImportGraph of loaded packages.
+// Nodes are package IDs, which are same as import paths unless test variants loaded.
+type ImportGraph struct {
+	ids       []string
+	packages  map[string]*packages.Package
+	imports   map[string][]string
+	importers map[string][]string
+}
+
+func NewImportGraph(pkgs []*packages.Package) *ImportGraph {
+	s := pkgSet{}
+	for _, pkg := range pkgs {
+		s.add(pkg)
+	}
+
+	g := &ImportGraph{
+		packages:  map[string]*packages.Package{},
+		imports:   map[string][]string{},
+		importers: map[string][]string{},
+	}
+
+	for _, pkg := range s.allPackages() {
+		g.ids = append(g.ids, pkg.ID)
+		g.packages[pkg.ID] = pkg
+
+		for _, importPath := range sortedImportPaths(pkg) {
+			imported := pkg.Imports[importPath]
+			g.imports[pkg.ID] = append(g.imports[pkg.ID], imported.ID)
+			g.importers[imported.ID] = append(g.importers[imported.ID], pkg.ID)
+		}
+	}
+
+	for id := range g.imports {
+		sort.Strings(g.imports[id])
+	}
+
+	for id := range g.importers {
+		sort.Strings(g.importers[id])
+	}
+
+	return g
+}
+
+func (g *ImportGraph) Package(id string) *packages.Package {
+	return g.packages[id]
+}
+
+// Packages returns IDs of packages in topological order, dependencies first,
+// filtered by kinds when provided.
+func (g *ImportGraph) Packages(kinds ...PackageKind) []string {
+	if len(kinds) == 0 {
+		return append([]string{}, g.ids...)
+	}
+
+	list := make([]string, 0)
+	for _, id := range g.ids {
+		kind := PackageKindOf(g.packages[id])
+		for _, k := range kinds {
+			if kind == k {
+				list = append(list, id)
+				break
+			}
+		}
+	}
+	return list
+}
+
+func (g *ImportGraph) KindOf(id string) PackageKind {
+	if pkg, ok := g.packages[id]; ok {
+		return PackageKindOf(pkg)
+	}
+	return PackageKindUnknown
+}
+
+// Imports returns IDs of packages imported by id directly
+func (g *ImportGraph) Imports(id string) []string {
+	return append([]string{}, g.imports[id]...)
+}
+
+// Importers returns IDs of packages which import id directly
+func (g *ImportGraph) Importers(id string) []string {
+	return append([]string{}, g.importers[id]...)
+}
+
+// TransitiveImports returns IDs of all dependencies of id in topological order
+func (g *ImportGraph) TransitiveImports(id string) []string {
+	return g.reachable(id, g.imports)
+}
+
+// TransitiveImporters returns IDs of all packages depending on id in topological order
+func (g *ImportGraph) TransitiveImporters(id string) []string {
+	return g.reachable(id, g.importers)
+}
+
+func (g *ImportGraph) reachable(id string, edges map[string][]string) []string {
+	visited := map[string]bool{}
+	queue := append([]string{}, edges[id]...)
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		if visited[next] {
+			continue
+		}
+		visited[next] = true
+		queue = append(queue, edges[next]...)
+	}
+
+	list := make([]string, 0, len(visited))
+	for _, i := range g.ids {
+		if visited[i] && i != id {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// ShortestPath returns IDs of packages on the shortest import chain from one package to another,
+// including both ends. nil returned when to is not imported by from.
+func (g *ImportGraph) ShortestPath(from string, to string) []string {
+	if _, ok := g.packages[from]; !ok {
+		return nil
+	}
+
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if id == to {
+			path := []string{}
+			for ; id != ""; id = prev[id] {
+				path = append([]string{id}, path...)
+			}
+			return path
+		}
+
+		for _, next := range g.imports[id] {
+			if _, ok := prev[next]; !ok {
+				prev[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Cycles returns import cycles which appear when test variants are merged into the packages they are compiled from.
+// Like in-package tests of "p" import "q", which imports "p".
+// External test package "p_test" is a package of its own, importing "q" which imports "p" is not a cycle.
+// Each cycle is a sorted list of import paths.
+func (g *ImportGraph) Cycles() [][]string {
+	merged := map[string]map[string]bool{}
+
+	for _, id := range g.ids {
+		from := pathOfVariant(g.packages[id])
+		if merged[from] == nil {
+			merged[from] = map[string]bool{}
+		}
+		for _, importedID := range g.imports[id] {
+			if to := pathOfVariant(g.packages[importedID]); to != from {
+				merged[from][to] = true
+			}
+		}
+	}
+
+	cycles := make([][]string, 0)
+
+	for _, component := range stronglyConnectedComponents(merged) {
+		if len(component) > 1 {
+			cycles = append(cycles, component)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+
+	return cycles
+}
+
+// pathOfVariant returns the import path of pkg,
+// which merges "p [p.test]" into "p" and "q [p.test]" into "q".
+func pathOfVariant(pkg *packages.Package) string {
+	if pkg.PkgPath != "" {
+		return pkg.PkgPath
+	}
+	if i := strings.Index(pkg.ID, " ["); i > 0 {
+		return pkg.ID[0:i]
+	}
+	return pkg.ID
+}
+
+// packageUnderTest returns the import path of the package under test for test variants,
+// otherwise the import path of pkg.
+func packageUnderTest(pkg *packages.Package) string {
+	if pkg.ForTest != "" {
+		return pkg.ForTest
+	}
+
+	// "p [p.test]" or "p_test [p.test]"
+	if i := strings.Index(pkg.ID, " ["); i > 0 && strings.HasSuffix(pkg.ID, ".test]") {
+		if strings.HasSuffix(pkg.ID[0:i], "_test") {
+			return strings.TrimSuffix(pkg.ID[i+2:], ".test]")
+		}
+		return pkg.ID[0:i]
+	}
+
+	// "p.test"
+	if pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test") {
+		return strings.TrimSuffix(pkg.ID, ".test")
+	}
+
+	// "p_test"
+	if strings.HasSuffix(pkg.ID, "_test") && strings.HasSuffix(pkg.Name, "_test") {
+		return strings.TrimSuffix(pkg.ID, "_test")
+	}
+
+	if pkg.PkgPath != "" {
+		return pkg.PkgPath
+	}
+
+	return pkg.ID
+}
+
+// stronglyConnectedComponents by Tarjan's algorithm
+func stronglyConnectedComponents(edges map[string]map[string]bool) [][]string {
+	nodes := make([]string, 0, len(edges))
+	for node := range edges {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	index := 0
+	indexes := map[string]int{}
+	lowLinks := map[string]int{}
+	onStack := map[string]bool{}
+	stack := make([]string, 0)
+	components := make([][]string, 0)
+
+	var strongConnect func(node string)
+
+	strongConnect = func(node string) {
+		indexes[node] = index
+		lowLinks[node] = index
+		index++
+
+		stack = append(stack, node)
+		onStack[node] = true
+
+		nexts := make([]string, 0, len(edges[node]))
+		for next := range edges[node] {
+			nexts = append(nexts, next)
+		}
+		sort.Strings(nexts)
+
+		for _, next := range nexts {
+			if _, ok := indexes[next]; !ok {
+				strongConnect(next)
+				if lowLinks[next] < lowLinks[node] {
+					lowLinks[node] = lowLinks[next]
+				}
+			} else if onStack[next] && indexes[next] < lowLinks[node] {
+				lowLinks[node] = indexes[next]
+			}
+		}
+
+		if lowLinks[node] == indexes[node] {
+			component := make([]string, 0)
+			for {
+				n := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[n] = false
+				component = append(component, n)
+				if n == node {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, ok := indexes[node]; !ok {
+			strongConnect(node)
+		}
+	}
+
+	return components
+}
@@ -0,0 +1,94 @@
+package packagesx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestImportGraph(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	g := pkg.ImportGraph()
+	NewWithT(t).Expect(pkg.ImportGraph()).To(BeIdenticalTo(g))
+
+	fixtures := "github.com/go-courier/packagesx/__fixtures__"
+	sub := "github.com/go-courier/packagesx/__fixtures__/sub"
+
+	NewWithT(t).Expect(g.Imports(fixtures)).To(Equal([]string{"fmt", sub, "strings", "time"}))
+	NewWithT(t).Expect(g.Importers(sub)).To(Equal([]string{fixtures}))
+	NewWithT(t).Expect(g.Importers("strings")).To(ContainElement(fixtures))
+
+	NewWithT(t).Expect(g.TransitiveImports(sub)).To(BeEmpty())
+	NewWithT(t).Expect(g.TransitiveImports(fixtures)).To(ContainElements("strings", "unicode/utf8", sub))
+	NewWithT(t).Expect(g.TransitiveImporters("unicode/utf8")).To(ContainElements("strings", fixtures))
+
+	path := g.ShortestPath(fixtures, "unicode/utf8")
+	NewWithT(t).Expect(path[0]).To(Equal(fixtures))
+	NewWithT(t).Expect(path[len(path)-1]).To(Equal("unicode/utf8"))
+	NewWithT(t).Expect(len(path)).To(Equal(3))
+	NewWithT(t).Expect(g.ShortestPath(sub, "strings")).To(BeNil())
+
+	NewWithT(t).Expect(g.KindOf("strings")).To(Equal(PackageKindStdlib))
+	NewWithT(t).Expect(g.KindOf(fixtures)).To(Equal(PackageKindModule))
+	NewWithT(t).Expect(g.Packages(PackageKindModule)).To(Equal([]string{sub, fixtures}))
+	NewWithT(t).Expect(g.Packages(PackageKindThirdParty)).To(BeEmpty())
+
+	NewWithT(t).Expect(g.Cycles()).To(BeEmpty())
+}
+
+func TestImportGraphKinds(t *testing.T) {
+	main := &packages.Package{ID: "github.com/x/main", PkgPath: "github.com/x/main", Module: &packages.Module{Path: "github.com/x/main", Main: true}}
+	dep := &packages.Package{ID: "github.com/y/dep", PkgPath: "github.com/y/dep", Module: &packages.Module{Path: "github.com/y/dep"}}
+	std := &packages.Package{ID: "net/http", PkgPath: "net/http"}
+	noModule := &packages.Package{ID: "gopkg.in/yaml.v2", PkgPath: "gopkg.in/yaml.v2"}
+
+	main.Imports = map[string]*packages.Package{dep.ID: dep, std.ID: std, noModule.ID: noModule}
+
+	g := NewImportGraph([]*packages.Package{main})
+
+	NewWithT(t).Expect(g.Packages(PackageKindModule)).To(Equal([]string{main.ID}))
+	NewWithT(t).Expect(g.Packages(PackageKindStdlib)).To(Equal([]string{std.ID}))
+	NewWithT(t).Expect(g.Packages(PackageKindThirdParty)).To(Equal([]string{dep.ID, noModule.ID}))
+	NewWithT(t).Expect(g.Packages(PackageKindStdlib, PackageKindThirdParty)).To(HaveLen(3))
+	NewWithT(t).Expect(g.KindOf("unknown")).To(Equal(PackageKindUnknown))
+}
+
+func TestImportGraphCyclesOfTestVariants(t *testing.T) {
+	p := &packages.Package{ID: "x/p", PkgPath: "x/p", Name: "p"}
+	q := &packages.Package{ID: "x/q", PkgPath: "x/q", Name: "q", Imports: map[string]*packages.Package{"x/p": p}}
+
+	pForTest := &packages.Package{ID: "x/p [x/p.test]", PkgPath: "x/p", Name: "p"}
+	qForTest := &packages.Package{ID: "x/q [x/p.test]", PkgPath: "x/q", Name: "q", Imports: map[string]*packages.Package{"x/p": pForTest}}
+	xTest := &packages.Package{ID: "x/p_test [x/p.test]", PkgPath: "x/p_test", Name: "p_test", Imports: map[string]*packages.Package{
+		"x/p": pForTest,
+		"x/q": qForTest,
+	}}
+	testMain := &packages.Package{ID: "x/p.test", PkgPath: "x/p.test", Name: "main", Imports: map[string]*packages.Package{
+		"x/p":      pForTest,
+		"x/p_test": xTest,
+	}}
+
+	g := NewImportGraph([]*packages.Package{p, q, testMain})
+
+	NewWithT(t).Expect(g.ShortestPath(xTest.ID, p.ID)).To(BeNil())
+	NewWithT(t).Expect(g.ShortestPath(xTest.ID, pForTest.ID)).To(Equal([]string{xTest.ID, pForTest.ID}))
+	NewWithT(t).Expect(g.Cycles()).To(BeEmpty())
+
+	t.Run("in-package test imports package importing the package under test", func(t *testing.T) {
+		pForTest := &packages.Package{ID: "x/p [x/p.test]", PkgPath: "x/p", Name: "p"}
+		qForTest := &packages.Package{ID: "x/q [x/p.test]", PkgPath: "x/q", Name: "q", Imports: map[string]*packages.Package{"x/p": pForTest}}
+		pForTest.Imports = map[string]*packages.Package{"x/q": qForTest}
+		testMain := &packages.Package{ID: "x/p.test", PkgPath: "x/p.test", Name: "main", Imports: map[string]*packages.Package{
+			"x/p": pForTest,
+		}}
+
+		NewWithT(t).Expect(NewImportGraph([]*packages.Package{p, q, testMain}).Cycles()).To(Equal([][]string{{"x/p", "x/q"}}))
+	})
+
+	NewWithT(t).Expect(NewImportGraph([]*packages.Package{p, q}).Cycles()).To(BeEmpty())
+}
@@ -0,0 +1,262 @@
+package packagesx
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// universe holds state shared by packages loaded together
+type universe struct {
+	// config and patterns which packages loaded with, nil when not loaded by LoadWithConfig
+	config   *packages.Config
+	patterns []string
+	// matched is IDs of packages matched by patterns, or passed to NewPackages
+	matched []string
+	// handles to update when packages loaded again
+	handles []*Package
+
+	// syntaxMu serializes loading of syntax on demand and reloading
+	syntaxMu sync.Mutex
+	overlay  map[string][]byte
+
+	factsMu   sync.Mutex
+	cacheDir  string
+	factsKeys map[*packages.Package]string
+	// factsBuild is go env and build flags of config, see factsBuildOf
+	factsBuild string
+
+	mu            sync.Mutex
+	allPackages   []*packages.Package
+	importGraph   *ImportGraph
+	vendorIndex   *vendorIndex
+	fileIndex     fileIndex
+	symbolIndexes map[*packages.Package]*symbolIndex
+	// checked holds syntax of dependencies loaded on demand, see ensureSyntax
+	checked map[*packages.Package]*checkedSyntax
+	// commentScanners cached by file, files replaced by reload are dropped
+	commentScanners map[*ast.File]*CommentScanner
+	// directiveParser with prefixes set by SetDirectivePrefixes, kept when reset
+	directiveParser DirectiveParser
+}
+
+func newUniverse(allPackages []*packages.Package) *universe {
+	u := &universe{
+		overlay:   map[string][]byte{},
+		factsKeys: map[*packages.Package]string{},
+	}
+	u.reset(allPackages)
+	return u
+}
+
+// reset drops all state derived from packages, must be called with u.mu held or before u shared.
+func (u *universe) reset(allPackages []*packages.Package) {
+	u.allPackages = allPackages
+	u.importGraph = nil
+	u.vendorIndex = nil
+	u.fileIndex = nil
+	u.symbolIndexes = map[*packages.Package]*symbolIndex{}
+	u.checked = map[*packages.Package]*checkedSyntax{}
+	u.commentScanners = map[*ast.File]*CommentScanner{}
+
+	for _, pkg := range allPackages {
+		u.fileIndex = u.fileIndex.add(pkg, pkg.Syntax, pkg.Types, pkg.TypesInfo)
+	}
+	u.fileIndex = u.fileIndex.sorted()
+}
+
+// checkedSyntax is syntax of package with the types and info it checked with
+type checkedSyntax struct {
+	files []*ast.File
+	types *types.Package
+	info  *types.Info
+}
+
+// syntaxOf returns syntax of pkg, nil when not loaded.
+// For dependencies loaded on demand, types and info are not the ones of pkg.Types.
+func (u *universe) syntaxOf(pkg *packages.Package) *checkedSyntax {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.syntaxOfLocked(pkg)
+}
+
+func (u *universe) syntaxOfLocked(pkg *packages.Package) *checkedSyntax {
+	if pkg.TypesInfo != nil {
+		return &checkedSyntax{files: pkg.Syntax, types: pkg.Types, info: pkg.TypesInfo}
+	}
+	return u.checked[pkg]
+}
+
+func (u *universe) lookupFile(pos token.Pos) *fileRange {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.fileIndex.lookup(pos)
+}
+
+func (u *universe) symbolIndexOf(pkg *packages.Package) *symbolIndex {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if idx, ok := u.symbolIndexes[pkg]; ok {
+		return idx
+	}
+
+	var info *types.Info
+	if s := u.syntaxOfLocked(pkg); s != nil {
+		info = s.info
+	}
+
+	idx := newSymbolIndex(pkg.Fset, info)
+	u.symbolIndexes[pkg] = idx
+	return idx
+}
+
+func (u *universe) commentScannerOf(fset *token.FileSet, file *ast.File) *CommentScanner {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if scanner, ok := u.commentScanners[file]; ok {
+		return scanner
+	}
+
+	scanner := NewCommentScanner(fset, file)
+	u.commentScanners[file] = scanner
+	return scanner
+}
+
+type symbolIndex struct {
+	idents map[types.Object]*ast.Ident
+	lines  map[identLine]*ast.Ident
+}
+
+// identLine locates a defined ident by line,
+// since objects loaded from export data keep file and line only.
+type identLine struct {
+	filename string
+	line     int
+	name     string
+}
+
+func newSymbolIndex(fset *token.FileSet, info *types.Info) *symbolIndex {
+	idx := &symbolIndex{
+		idents: map[types.Object]*ast.Ident{},
+		lines:  map[identLine]*ast.Ident{},
+	}
+
+	if info == nil {
+		return idx
+	}
+
+	idents := make([]*ast.Ident, 0, len(info.Defs))
+	for ident, def := range info.Defs {
+		if def != nil {
+			idents = append(idents, ident)
+		}
+	}
+
+	// first defined wins, to keep lookups stable between runs
+	sort.Slice(idents, func(i, j int) bool {
+		return idents[i].Pos() < idents[j].Pos()
+	})
+
+	for _, ident := range idents {
+		def := info.Defs[ident]
+
+		if _, ok := idx.idents[def]; !ok {
+			idx.idents[def] = ident
+		}
+
+		if fset != nil {
+			position := fset.Position(ident.Pos())
+			key := identLine{filename: position.Filename, line: position.Line, name: ident.Name}
+			if _, ok := idx.lines[key]; !ok {
+				idx.lines[key] = ident
+			}
+		}
+	}
+
+	return idx
+}
+
+type fileRange struct {
+	start token.Pos
+	end   token.Pos
+	pkg   *packages.Package
+	file  *ast.File
+	// types and info which file checked with,
+	// could be different from pkg.Types when syntax loaded on demand
+	types *types.Package
+	info  *types.Info
+}
+
+// fileIndex is a list of file ranges sorted by start pos
+type fileIndex []fileRange
+
+// add appends ranges of files, sorted should be called before lookup
+func (idx fileIndex) add(pkg *packages.Package, files []*ast.File, tpkg *types.Package, info *types.Info) fileIndex {
+	if pkg.Fset == nil {
+		return idx
+	}
+
+	for _, file := range files {
+		tokenFile := pkg.Fset.File(file.Pos())
+		if tokenFile == nil {
+			continue
+		}
+		idx = append(idx, fileRange{
+			start: token.Pos(tokenFile.Base()),
+			end:   token.Pos(tokenFile.Base() + tokenFile.Size()),
+			pkg:   pkg,
+			file:  file,
+			types: tpkg,
+			info:  info,
+		})
+	}
+
+	return idx
+}
+
+func (idx fileIndex) sorted() fileIndex {
+	// files shared by variants of package resolve to the production one
+	sort.SliceStable(idx, func(i, j int) bool {
+		if idx[i].start == idx[j].start {
+			return VariantOf(idx[i].pkg) < VariantOf(idx[j].pkg)
+		}
+		return idx[i].start < idx[j].start
+	})
+
+	return idx
+}
+
+func (idx fileIndex) remove(pkgs map[*packages.Package]bool) fileIndex {
+	list := make(fileIndex, 0, len(idx))
+	for _, r := range idx {
+		if !pkgs[r.pkg] {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+func (idx fileIndex) lookup(pos token.Pos) *fileRange {
+	if !pos.IsValid() {
+		return nil
+	}
+
+	// first range which ends not before pos
+	i := sort.Search(len(idx), func(i int) bool {
+		return idx[i].end >= pos
+	})
+
+	if i < len(idx) && idx[i].start <= pos {
+		r := idx[i]
+		return &r
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package packagesx
+
+import (
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestSymbolIndex(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	NewWithT(t).Expect(pkg.u().symbolIndexOf(pkg.Package)).To(BeIdenticalTo(pkg.u().symbolIndexOf(pkg.Package)))
+
+	for _, name := range []string{"Date", "Test", "String"} {
+		tpeName := pkg.TypeName(name)
+		NewWithT(t).Expect(tpeName).NotTo(BeNil())
+		NewWithT(t).Expect(pkg.TypeName(name)).To(BeIdenticalTo(tpeName))
+		NewWithT(t).Expect(pkg.IdentOf(tpeName).Name).To(Equal(name))
+	}
+
+	NewWithT(t).Expect(pkg.Const("A").Name()).To(Equal("A"))
+	NewWithT(t).Expect(pkg.Func("Print").Name()).To(Equal("Print"))
+	NewWithT(t).Expect(pkg.Var("test2").Name()).To(Equal("test2"))
+
+	NewWithT(t).Expect(pkg.Const("Print")).To(BeNil())
+	NewWithT(t).Expect(pkg.Func("Unknown")).To(BeNil())
+
+	t.Run("IdentOf in other packages", func(t *testing.T) {
+		sub := NewPackage(pkg.Pkg("github.com/go-courier/packagesx/__fixtures__/sub"))
+		ident := pkg.IdentOf(sub.Func("CurryCall"))
+		NewWithT(t).Expect(ident).NotTo(BeNil())
+		NewWithT(t).Expect(ident.Name).To(Equal("CurryCall"))
+	})
+}
+
+func TestFileIndex(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	for _, p := range pkg.AllPackages {
+		for _, file := range p.Syntax {
+			for _, poser := range []Poser{file, file.Name, &ast.Ident{NamePos: file.End() - 1}} {
+				locatedPkg, locatedInfo, locatedFile := pkg.Locate(poser)
+				NewWithT(t).Expect(locatedPkg).To(BeIdenticalTo(p))
+				NewWithT(t).Expect(locatedInfo).To(BeIdenticalTo(p.TypesInfo))
+				NewWithT(t).Expect(locatedFile).To(BeIdenticalTo(file))
+			}
+		}
+	}
+
+	t.Run("comments before package clause", func(t *testing.T) {
+		file := pkg.FileOf(pkg.TypeName("Date"))
+		NewWithT(t).Expect(pkg.FileOf(file.Comments[0])).To(BeIdenticalTo(file))
+	})
+
+	t.Run("invalid pos", func(t *testing.T) {
+		ident := &ast.Ident{NamePos: token.NoPos}
+
+		p, info, file := pkg.Locate(ident)
+		NewWithT(t).Expect(p).To(BeNil())
+		NewWithT(t).Expect(info).To(BeNil())
+		NewWithT(t).Expect(file).To(BeNil())
+		NewWithT(t).Expect(pkg.PkgOf(ident)).To(BeNil())
+		NewWithT(t).Expect(pkg.PkgInfoOf(ident)).To(BeNil())
+	})
+}
+
+func TestCommentScannerCache(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/scanner\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package scanner\n\n// Value doc\nconst Value = 1\n")
+
+	pkgs, err := LoadWithConfig(&packages.Config{Dir: dir}, ".")
+	NewWithT(t).Expect(err).To(BeNil())
+
+	pkg := pkgs[0]
+	file := pkg.Syntax[0]
+
+	scanner := pkg.u().commentScannerOf(pkg.Fset, file)
+	NewWithT(t).Expect(pkg.u().commentScannerOf(pkg.Fset, file)).To(BeIdenticalTo(scanner))
+	NewWithT(t).Expect(pkg.CommentsOf(pkg.IdentOf(pkg.Const("Value")))).To(Equal("Value doc"))
+	NewWithT(t).Expect(pkg.u().commentScanners).To(HaveLen(1))
+
+	err = pkg.Reload(map[string][]byte{
+		filepath.Join(dir, "main.go"): []byte("package scanner\n\n// Value changed\nconst Value = 1\n"),
+	})
+	NewWithT(t).Expect(err).To(BeNil())
+
+	NewWithT(t).Expect(pkg.u().commentScanners).To(BeEmpty())
+	NewWithT(t).Expect(pkg.CommentsOf(pkg.IdentOf(pkg.Const("Value")))).To(Equal("Value changed"))
+}
@@ -0,0 +1,251 @@
+package packagesx
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// BuildConfig is one configuration of build constraints.
+// Empty GOOS or GOARCH means the one of the environment.
+type BuildConfig struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+func (c BuildConfig) String() string {
+	s := c.GOOS + "/" + c.GOARCH
+	if len(c.Tags) > 0 {
+		s += "," + strings.Join(c.Tags, ",")
+	}
+	return s
+}
+
+func (c BuildConfig) apply(cfg packages.Config) packages.Config {
+	env := cfg.Env
+	if env == nil {
+		env = os.Environ()
+	}
+
+	env = append([]string{}, env...)
+
+	if c.GOOS != "" {
+		env = append(env, "GOOS="+c.GOOS)
+	}
+	if c.GOARCH != "" {
+		env = append(env, "GOARCH="+c.GOARCH)
+	}
+
+	cfg.Env = env
+
+	if len(c.Tags) > 0 {
+		cfg.BuildFlags = withTags(cfg.BuildFlags, c.Tags)
+	}
+
+	return cfg
+}
+
+// withTags merges tags into -tags of buildFlags,
+// since only the last -tags takes effect.
+func withTags(buildFlags []string, tags []string) []string {
+	flags := make([]string, 0, len(buildFlags)+1)
+	merged := make([]string, 0)
+
+	addTags := func(list string) {
+		for _, tag := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if !containsString(merged, tag) {
+				merged = append(merged, tag)
+			}
+		}
+	}
+
+	for i := 0; i < len(buildFlags); i++ {
+		flag := buildFlags[i]
+		name := strings.TrimPrefix(strings.TrimPrefix(flag, "-"), "-")
+
+		switch {
+		case strings.HasPrefix(name, "tags="):
+			addTags(strings.TrimPrefix(name, "tags="))
+		case name == "tags" && i+1 < len(buildFlags):
+			i++
+			addTags(buildFlags[i])
+		default:
+			flags = append(flags, flag)
+		}
+	}
+
+	addTags(strings.Join(tags, ","))
+
+	return append(flags, "-tags="+strings.Join(merged, ","))
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// MatrixPackage is the merged view of one package loaded under several build configurations.
+type MatrixPackage struct {
+	PkgPath string
+	// Configs which the package loaded under
+	Configs []BuildConfig
+	// Packages in the same order of Configs, nil when package not matched under the config
+	Packages []*Package
+}
+
+// LoadMatrix loads patterns under each of configs.
+// Packages excluded by build constraints under some of configs are absent under those only,
+// ErrNoPackages returned when patterns match no package under any config.
+// Returned packages are in order of first matched.
+func LoadMatrix(cfg *packages.Config, configs []BuildConfig, patterns ...string) ([]*MatrixPackage, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no build configs")
+	}
+
+	c := packages.Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+
+	list := make([]*MatrixPackage, 0)
+	matrixPackages := map[string]*MatrixPackage{}
+
+	var errNoPackages *ErrNoPackages
+
+	for i, config := range configs {
+		pkgs, err := LoadWithConfig(ptrOfConfig(config.apply(c)), patterns...)
+		if err != nil {
+			if errors.As(err, &errNoPackages) {
+				continue
+			}
+			return nil, fmt.Errorf("load under %s: %w", config, err)
+		}
+
+		for _, pkg := range pkgs {
+			m, ok := matrixPackages[pkg.PkgPath]
+			if !ok {
+				m = &MatrixPackage{
+					PkgPath:  pkg.PkgPath,
+					Configs:  configs,
+					Packages: make([]*Package, len(configs)),
+				}
+				matrixPackages[pkg.PkgPath] = m
+				list = append(list, m)
+			}
+			m.Packages[i] = pkg
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, errNoPackages
+	}
+
+	return list, nil
+}
+
+func ptrOfConfig(c packages.Config) *packages.Config {
+	return &c
+}
+
+// Package returns the package loaded under config
+func (m *MatrixPackage) Package(config BuildConfig) *Package {
+	for i := range m.Configs {
+		if m.Configs[i].String() == config.String() {
+			return m.Packages[i]
+		}
+	}
+	return nil
+}
+
+// Names returns sorted names of package-level objects under any of configs
+func (m *MatrixPackage) Names() []string {
+	set := map[string]bool{}
+	for _, pkg := range m.Packages {
+		if pkg == nil || pkg.Types == nil {
+			continue
+		}
+		for _, name := range pkg.Types.Scope().Names() {
+			set[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ConfigsOf returns configs which the package-level object named name exists under
+func (m *MatrixPackage) ConfigsOf(name string) []BuildConfig {
+	configs := make([]BuildConfig, 0)
+	for i, pkg := range m.Packages {
+		if pkg != nil && pkg.Types != nil && pkg.Types.Scope().Lookup(name) != nil {
+			configs = append(configs, m.Configs[i])
+		}
+	}
+	return configs
+}
+
+// Constrained returns names of package-level objects which only exist under part of configs,
+// with the configs they exist under.
+func (m *MatrixPackage) Constrained() map[string][]BuildConfig {
+	constrained := map[string][]BuildConfig{}
+	for _, name := range m.Names() {
+		if configs := m.ConfigsOf(name); len(configs) != len(m.Configs) {
+			constrained[name] = configs
+		}
+	}
+	return constrained
+}
+
+// ConfigConst is a const with configs it has the same value under
+type ConfigConst struct {
+	*types.Const
+	Configs []BuildConfig
+}
+
+// Const returns one ConfigConst when the value is the same under all configs the const exists under,
+// otherwise returns one ConfigConst per different value, in order of configs.
+func (m *MatrixPackage) Const(name string) []ConfigConst {
+	list := make([]ConfigConst, 0)
+
+	for i, pkg := range m.Packages {
+		if pkg == nil || pkg.Types == nil {
+			continue
+		}
+
+		c := pkg.Const(name)
+		if c == nil {
+			continue
+		}
+
+		found := false
+
+		for j := range list {
+			// types of different configs are never identical, compare by string
+			if list[j].Val().ExactString() == c.Val().ExactString() && list[j].Type().String() == c.Type().String() {
+				list[j].Configs = append(list[j].Configs, m.Configs[i])
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			list = append(list, ConfigConst{Const: c, Configs: []BuildConfig{m.Configs[i]}})
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,82 @@
+package packagesx
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestLoadMatrix(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	linux := BuildConfig{GOOS: "linux", GOARCH: "amd64"}
+	linuxDebug := BuildConfig{GOOS: "linux", GOARCH: "amd64", Tags: []string{"debug"}}
+	windows := BuildConfig{GOOS: "windows", GOARCH: "amd64"}
+
+	pkgs, err := LoadMatrix(&packages.Config{Dir: cwd}, []BuildConfig{linux, linuxDebug, windows}, "./__fixtures__/matrix")
+	NewWithT(t).Expect(err).To(BeNil())
+	NewWithT(t).Expect(pkgs).To(HaveLen(1))
+
+	m := pkgs[0]
+
+	NewWithT(t).Expect(m.PkgPath).To(Equal("github.com/go-courier/packagesx/__fixtures__/matrix"))
+	NewWithT(t).Expect(linuxDebug.String()).To(Equal("linux/amd64,debug"))
+	NewWithT(t).Expect(m.Package(windows).Const("Separator").Val().String()).To(Equal(`";"`))
+
+	t.Run("symbols under build constraints", func(t *testing.T) {
+		NewWithT(t).Expect(m.Names()).To(Equal([]string{"Common", "Debug", "Epoll", "Handle", "Separator", "Version"}))
+
+		NewWithT(t).Expect(m.Constrained()).To(Equal(map[string][]BuildConfig{
+			"Debug":  {linuxDebug},
+			"Epoll":  {linux, linuxDebug},
+			"Handle": {windows},
+		}))
+
+		NewWithT(t).Expect(m.ConfigsOf("Separator")).To(HaveLen(3))
+		NewWithT(t).Expect(m.ConfigsOf("Nope")).To(BeEmpty())
+	})
+
+	t.Run("per-configuration const values", func(t *testing.T) {
+		versions := m.Const("Version")
+		NewWithT(t).Expect(versions).To(HaveLen(1))
+		NewWithT(t).Expect(versions[0].Configs).To(HaveLen(3))
+
+		separators := m.Const("Separator")
+		NewWithT(t).Expect(separators).To(HaveLen(2))
+		NewWithT(t).Expect(separators[0].Val().String()).To(Equal(`":"`))
+		NewWithT(t).Expect(separators[0].Configs).To(Equal([]BuildConfig{linux, linuxDebug}))
+		NewWithT(t).Expect(separators[1].Val().String()).To(Equal(`";"`))
+		NewWithT(t).Expect(separators[1].Configs).To(Equal([]BuildConfig{windows}))
+
+		NewWithT(t).Expect(m.Const("Debug")[0].Configs).To(Equal([]BuildConfig{linuxDebug}))
+	})
+
+	t.Run("absent under configs excluded by build constraints", func(t *testing.T) {
+		pkgs, err := LoadMatrix(&packages.Config{Dir: cwd}, []BuildConfig{linux, windows}, "./__fixtures__/matrix/windowsonly")
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(pkgs).To(HaveLen(1))
+		NewWithT(t).Expect(pkgs[0].Package(linux)).To(BeNil())
+		NewWithT(t).Expect(pkgs[0].Package(windows).TypeName("Handle")).NotTo(BeNil())
+		NewWithT(t).Expect(pkgs[0].Constrained()).To(Equal(map[string][]BuildConfig{
+			"Handle": {windows},
+		}))
+
+		_, err = LoadMatrix(&packages.Config{Dir: cwd}, []BuildConfig{linux}, "./__fixtures__/matrix/windowsonly")
+		errNoPackages := &ErrNoPackages{}
+		NewWithT(t).Expect(errors.As(err, &errNoPackages)).To(BeTrue())
+	})
+
+	t.Run("tags merged with build flags", func(t *testing.T) {
+		pkgs, err := LoadMatrix(&packages.Config{Dir: cwd, BuildFlags: []string{"-tags", "debug"}}, []BuildConfig{linux, linuxDebug}, "./__fixtures__/matrix")
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(pkgs[0].ConfigsOf("Debug")).To(Equal([]BuildConfig{linux, linuxDebug}))
+	})
+}
+
+func TestWithTags(t *testing.T) {
+	NewWithT(t).Expect(withTags(nil, []string{"debug"})).To(Equal([]string{"-tags=debug"}))
+	NewWithT(t).Expect(withTags([]string{"-v", "-tags=a,b", "--tags", "c", "-race"}, []string{"b", "debug"})).To(Equal([]string{"-v", "-race", "-tags=a,b,c,debug"}))
+}
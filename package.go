@@ -1,9 +1,13 @@
 package packagesx
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"os"
+	"sort"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -12,29 +16,144 @@ type Poser interface {
 	Pos() token.Pos
 }
 
+// Load loads the package matched by pattern.
+// When pattern matches several packages, the first one is returned, see Matched for all of them.
 func Load(pattern string) (*Package, error) {
-	pkgs, err := packages.Load(&packages.Config{
-		Mode: packages.LoadAllSyntax,
+	pkgs, err := LoadWithConfig(&packages.Config{
+		Mode: DefaultLoadMode,
 	}, pattern)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return NewPackage(pkgs[0]), nil
+	return pkgs[0], nil
 }
 
-func NewPackage(pkg *packages.Package) *Package {
-	p := &Package{
-		Package: pkg,
+// LoadOne works like Load, but fails with *ErrMultiplePackages
+// when pattern matches several packages.
+func LoadOne(pattern string) (*Package, error) {
+	pkg, err := Load(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if matched := pkg.Matched(); len(matched) > 1 {
+		return nil, &ErrMultiplePackages{
+			Pattern: pattern,
+			Picked:  pkg.ID,
+			Matched: matched,
+		}
+	}
+
+	return pkg, nil
+}
+
+// LoadStrict works like Load, but fails with *LoadError
+// when any package in AllPackages is ill-typed.
+func LoadStrict(pattern string) (*Package, error) {
+	pkg, err := Load(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pkg.Err(); err != nil {
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+// LoadWithConfig loads all packages matched by patterns with cfg.
+// Returned packages share one token.FileSet and one AllPackages universe.
+func LoadWithConfig(cfg *packages.Config, patterns ...string) ([]*Package, error) {
+	c := packages.Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+
+	if c.Mode == 0 {
+		c.Mode = DefaultLoadMode
+	}
+
+	if c.Fset == nil {
+		c.Fset = token.NewFileSet()
+	}
+
+	pkgs, err := packages.Load(&c, patterns...)
+	if err != nil {
+		return nil, err
 	}
 
+	if !containsAnyMatched(pkgs) {
+		dir := c.Dir
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+
+		return nil, &ErrNoPackages{
+			Patterns: patterns,
+			Dir:      dir,
+			Err:      ErrorsOf(pkgs...),
+		}
+	}
+
+	list := NewPackages(pkgs...)
+
+	u := list[0].u()
+	u.config = &c
+	u.patterns = patterns
+
+	return list, nil
+}
+
+// containsAnyMatched reports whether pkgs contains any package other than
+// the placeholders created by the build system for unmatched patterns.
+func containsAnyMatched(pkgs []*packages.Package) bool {
+	for _, pkg := range pkgs {
+		if pkg.Name != "" || len(pkg.GoFiles) > 0 || len(pkg.Errors) == 0 {
+			return true
+		}
+		for _, err := range pkg.Errors {
+			if err.Kind != packages.ListError {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func NewPackage(pkg *packages.Package) *Package {
+	return NewPackages(pkg)[0]
+}
+
+// NewPackages wraps pkgs with one AllPackages universe shared by all of them.
+func NewPackages(pkgs ...*packages.Package) []*Package {
 	s := pkgSet{}
-	s.add(pkg)
+	for _, pkg := range pkgs {
+		s.add(pkg)
+	}
 
-	p.AllPackages = s.allPackages()
+	allPackages := s.allPackages()
+	u := newUniverse(allPackages)
 
-	return p
+	list := make([]*Package, len(pkgs))
+	for i := range pkgs {
+		list[i] = &Package{
+			Package:     pkgs[i],
+			AllPackages: allPackages,
+			universe:    u,
+		}
+	}
+
+	u.handles = list
+
+	u.matched = make([]string, len(pkgs))
+	for i := range pkgs {
+		u.matched[i] = pkgs[i].ID
+	}
+
+	return list
 }
 
 type pkgSet map[string]*packages.Package
@@ -49,128 +168,404 @@ func (s pkgSet) add(pkg *packages.Package) {
 	}
 }
 
+// allPackages returns packages in topological order, dependencies first.
+// Packages and imports are visited in order of ID, so the result is stable between runs.
 func (s pkgSet) allPackages() []*packages.Package {
-	list := make([]*packages.Package, 0)
+	ids := make([]string, 0, len(s))
 	for id := range s {
-		list = append(list, s[id])
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	list := make([]*packages.Package, 0, len(s))
+	visited := map[string]bool{}
+
+	var visit func(pkg *packages.Package)
+
+	visit = func(pkg *packages.Package) {
+		if visited[pkg.ID] {
+			return
+		}
+		visited[pkg.ID] = true
+
+		for _, importPath := range sortedImportPaths(pkg) {
+			visit(pkg.Imports[importPath])
+		}
+
+		list = append(list, pkg)
+	}
+
+	for _, id := range ids {
+		visit(s[id])
 	}
+
 	return list
 }
 
+func sortedImportPaths(pkg *packages.Package) []string {
+	importPaths := make([]string, 0, len(pkg.Imports))
+	for importPath := range pkg.Imports {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+	return importPaths
+}
+
 type Package struct {
 	*packages.Package
+	// AllPackages lists all packages loaded together in topological order, dependencies first.
+	// Packages without dependency relationship are ordered by ID.
 	AllPackages []*packages.Package
+
+	universe *universe
 }
 
-func (p *Package) Const(name string) *types.Const {
-	for ident, def := range p.TypesInfo.Defs {
-		if typeConst, ok := def.(*types.Const); ok {
-			if ident.Name == name {
-				return typeConst
-			}
+func (prog *Package) u() *universe {
+	if prog.universe == nil {
+		prog.universe = newUniverse(prog.AllPackages)
+		prog.universe.handles = []*Package{prog}
+	}
+	return prog.universe
+}
+
+// Matched returns IDs of packages matched by patterns which prog loaded with,
+// in the order returned by LoadWithConfig, which Load picks the first of.
+func (prog *Package) Matched() []string {
+	return append([]string{}, prog.u().matched...)
+}
+
+// Walk visits the package and all its dependencies in order of AllPackages,
+// until visit returns false.
+func (prog *Package) Walk(visit func(pkg *packages.Package) bool) {
+	reachable := pkgSet{}
+	reachable.add(prog.Package)
+
+	for _, pkg := range prog.AllPackages {
+		if _, ok := reachable[pkg.ID]; !ok {
+			continue
 		}
+		if !visit(pkg) {
+			return
+		}
+	}
+}
+
+func (prog *Package) ImportGraph() *ImportGraph {
+	u := prog.u()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.importGraph == nil {
+		u.importGraph = NewImportGraph(u.allPackages)
+	}
+	return u.importGraph
+}
+
+// Err returns *LoadError when any package in AllPackages contains errors.
+func (prog *Package) Err() error {
+	return ErrorsOf(prog.AllPackages...)
+}
+
+func (p *Package) Const(name string) *types.Const {
+	if typeConst, ok := p.lookup(name).(*types.Const); ok {
+		return typeConst
 	}
 	return nil
 }
 
 func (p *Package) TypeName(name string) *types.TypeName {
-	for ident, def := range p.TypesInfo.Defs {
-		if typeName, ok := def.(*types.TypeName); ok {
-			if ident.Name == name {
-				return typeName
-			}
-		}
+	if typeName, ok := p.lookup(name).(*types.TypeName); ok {
+		return typeName
 	}
 	return nil
 }
 
 func (p *Package) Var(name string) *types.Var {
-	for ident, def := range p.TypesInfo.Defs {
-		if typeVar, ok := def.(*types.Var); ok {
-			if ident.Name == name {
-				return typeVar
-			}
-		}
+	if typeVar, ok := p.lookup(name).(*types.Var); ok {
+		return typeVar
 	}
 	return nil
 }
 
 func (p *Package) Func(name string) *types.Func {
-	for ident, def := range p.TypesInfo.Defs {
-		if typeFunc, ok := def.(*types.Func); ok {
-			if ident.Name == name {
-				return typeFunc
-			}
+	if typeFunc, ok := p.lookup(name).(*types.Func); ok {
+		return typeFunc
+	}
+	return nil
+}
+
+// lookup resolves package-level object only
+func (p *Package) lookup(name string) types.Object {
+	if p.Types == nil {
+		return nil
+	}
+	return p.Types.Scope().Lookup(name)
+}
+
+// LocalVar returns the first variable named name declared in func funcName,
+// including parameters, results and variables of nested blocks.
+func (p *Package) LocalVar(funcName string, name string) *types.Var {
+	typeFunc := p.Func(funcName)
+	if typeFunc == nil || typeFunc.Scope() == nil {
+		return nil
+	}
+	return lookupVarInScope(typeFunc.Scope(), name)
+}
+
+func lookupVarInScope(scope *types.Scope, name string) *types.Var {
+	if typeVar, ok := scope.Lookup(name).(*types.Var); ok {
+		return typeVar
+	}
+	for i := 0; i < scope.NumChildren(); i++ {
+		if typeVar := lookupVarInScope(scope.Child(i), name); typeVar != nil {
+			return typeVar
 		}
 	}
 	return nil
 }
 
+// Field returns field name of type typeName, including promoted fields of embedded types.
+func (p *Package) Field(typeName string, name string) *types.Var {
+	tpeName := p.TypeName(typeName)
+	if tpeName == nil {
+		return nil
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(tpeName.Type(), true, p.Types, name)
+	if typeVar, ok := obj.(*types.Var); ok && typeVar.IsField() {
+		return typeVar
+	}
+	return nil
+}
+
+// Method returns method methodName of type typeName, which could be declared with
+// pointer receiver or promoted from embedded fields.
+func (p *Package) Method(typeName string, methodName string) *types.Func {
+	tpeName := p.TypeName(typeName)
+	if tpeName == nil {
+		return nil
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(tpeName.Type(), true, p.Types, methodName)
+	if typeFunc, ok := obj.(*types.Func); ok {
+		return typeFunc
+	}
+	return nil
+}
+
+// Pkg returns package importPath in AllPackages.
+// When loaded with Tests enabled, the production variant is preferred.
+// Vendored packages could be found by canonical or vendored import path.
 func (prog *Package) Pkg(importPath string) *packages.Package {
+	var found *packages.Package
+
 	for _, pkg := range prog.AllPackages {
-		pkgPath := pkg.PkgPath
-		if importPath == pkgPath {
+		if importPath != pkg.PkgPath {
+			continue
+		}
+		if VariantOf(pkg) == VariantProduction {
 			return pkg
 		}
+		if found == nil {
+			found = pkg
+		}
 	}
-	return nil
+
+	if found == nil {
+		found = prog.vendorIndex().pkgs[importPath]
+	}
+
+	return found
 }
 
-func (prog *Package) PkgOf(poser Poser) *types.Package {
-	for _, pkg := range prog.AllPackages {
-		for _, file := range pkg.Syntax {
-			if file.Pos() <= poser.Pos() && file.End() > poser.Pos() {
-				return pkg.Types
-			}
+// PackageOf wraps package importPath of AllPackages, sharing state with prog.
+func (prog *Package) PackageOf(importPath string) *Package {
+	pkg := prog.Pkg(importPath)
+	if pkg == nil {
+		return nil
+	}
+
+	p := prog.wrap(pkg)
+
+	u := prog.u()
+	u.mu.Lock()
+	u.handles = append(u.handles, p)
+	u.mu.Unlock()
+
+	return p
+}
+
+// wrap wraps pkg of AllPackages sharing state with prog, without registering it as a handle,
+// so AllPackages of it will not be updated when all packages loaded again.
+func (prog *Package) wrap(pkg *packages.Package) *Package {
+	return &Package{
+		Package:     pkg,
+		AllPackages: prog.AllPackages,
+		universe:    prog.u(),
+	}
+}
+
+// LookupQualified resolves qualified name in forms of
+// "import/path.Name", "import/path.Type.Method" and "import/path.Type.Field"
+// from any package in AllPackages.
+func (prog *Package) LookupQualified(qualifiedName string) (types.Object, error) {
+	start := strings.LastIndex(qualifiedName, "/") + 1
+	if !strings.Contains(qualifiedName[start:], ".") {
+		return nil, fmt.Errorf("invalid qualified name %s", qualifiedName)
+	}
+
+	pkg, names := prog.splitQualified(qualifiedName)
+	if pkg == nil {
+		importPath := qualifiedName[0 : start+strings.Index(qualifiedName[start:], ".")]
+		return nil, fmt.Errorf("package %s of %s is not part of the loaded packages", importPath, qualifiedName)
+	}
+
+	if pkg.Types == nil {
+		return nil, fmt.Errorf("package %s of %s is loaded without types", pkg.PkgPath, qualifiedName)
+	}
+
+	obj := pkg.Types.Scope().Lookup(names[0])
+	if obj == nil {
+		return nil, fmt.Errorf("%s is not declared in package %s", names[0], pkg.PkgPath)
+	}
+
+	switch len(names) {
+	case 1:
+		return obj, nil
+	case 2:
+		tpeName, ok := obj.(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s is not a type", pkg.PkgPath, names[0])
+		}
+		member, _, _ := types.LookupFieldOrMethod(tpeName.Type(), true, pkg.Types, names[1])
+		if member == nil {
+			return nil, fmt.Errorf("%s.%s has no field or method %s", pkg.PkgPath, names[0], names[1])
 		}
+		return member, nil
 	}
-	return nil
+
+	return nil, fmt.Errorf("invalid qualified name %s", qualifiedName)
 }
 
-func (prog *Package) PkgInfoOf(poser Poser) *types.Info {
-	for _, pkg := range prog.AllPackages {
-		for _, file := range pkg.Syntax {
-			if file.Pos() <= poser.Pos() && file.End() > poser.Pos() {
-				return pkg.TypesInfo
-			}
+// splitQualified picks the longest loaded import path as the package of qualifiedName,
+// since the last element of an import path could contain dots too, like gopkg.in/yaml.v2
+func (prog *Package) splitQualified(qualifiedName string) (*packages.Package, []string) {
+	start := strings.LastIndex(qualifiedName, "/") + 1
+
+	for i := len(qualifiedName) - 1; i > start; i-- {
+		if qualifiedName[i] != '.' {
+			continue
+		}
+		if pkg := prog.Pkg(qualifiedName[0:i]); pkg != nil {
+			return pkg, strings.Split(qualifiedName[i+1:], ".")
 		}
 	}
+
+	return nil, nil
+}
+
+// Locate returns the package, the info which the file checked with, and the file which contain poser.
+// Info could be different from pkg.TypesInfo, see PkgOf.
+func (prog *Package) Locate(poser Poser) (*packages.Package, *types.Info, *ast.File) {
+	if r := prog.u().lookupFile(poser.Pos()); r != nil {
+		return r.pkg, r.info, r.file
+	}
+	return nil, nil, nil
+}
+
+// PkgOf returns the types package which the file containing poser checked with.
+// For dependencies with syntax loaded on demand, it is checked from source,
+// and is not the pkg.Types loaded from export data.
+func (prog *Package) PkgOf(poser Poser) *types.Package {
+	if r := prog.u().lookupFile(poser.Pos()); r != nil {
+		return r.types
+	}
 	return nil
 }
 
-func (prog *Package) FileOf(poser Poser) *ast.File {
-	for _, pkg := range prog.AllPackages {
-		for _, file := range pkg.Syntax {
-			if file.Pos() <= poser.Pos() && file.End() > poser.Pos() {
-				return file
-			}
-		}
+// PkgInfoOf returns the info which the file containing poser checked with, see PkgOf.
+func (prog *Package) PkgInfoOf(poser Poser) *types.Info {
+	if r := prog.u().lookupFile(poser.Pos()); r != nil {
+		return r.info
 	}
 	return nil
 }
 
+func (prog *Package) FileOf(poser Poser) *ast.File {
+	_, _, file := prog.Locate(poser)
+	return file
+}
+
 func (prog *Package) IdentOf(obj types.Object) *ast.Ident {
-	pkgInfo := prog.Pkg(obj.Pkg().Path())
+	if obj == nil || obj.Pkg() == nil {
+		return nil
+	}
+
+	pkg := prog.declaringPkgOf(obj)
+	if pkg == nil {
+		return nil
+	}
+
+	if ident, ok := prog.u().symbolIndexOf(pkg).idents[obj]; ok {
+		return ident
+	}
+
+	// obj loaded from export data
+	p := prog.sourcePosOf(obj)
+	if p == obj.Pos() {
+		return nil
+	}
 
-	for ident, def := range pkgInfo.TypesInfo.Defs {
-		if def == obj {
-			return ident
+	position := pkg.Fset.Position(p)
+
+	return prog.u().symbolIndexOf(pkg).lines[identLine{
+		filename: position.Filename,
+		line:     position.Line,
+		name:     obj.Name(),
+	}]
+}
+
+// declaringPkgOf returns the package declaring obj by the file containing obj,
+// which could be a test variant, or by import path for obj loaded from export data.
+// Variants share files, the one checked obj is picked.
+func (prog *Package) declaringPkgOf(obj types.Object) *packages.Package {
+	if r := prog.u().lookupFile(obj.Pos()); r != nil {
+		if r.types != obj.Pkg() {
+			for _, pkg := range prog.AllPackages {
+				if pkg.Types == obj.Pkg() {
+					return pkg
+				}
+			}
 		}
+		return r.pkg
 	}
-	return nil
+	return prog.Pkg(obj.Pkg().Path())
 }
 
+// CommentsOf returns text of doc of node, see DocOf
 func (prog *Package) CommentsOf(node ast.Node) string {
-	file := prog.FileOf(node)
-	if file == nil {
-		return ""
+	return prog.DocOf(node).Text
+}
+
+// DocCommentOf returns text of the leading doc comment of node, see CommentScanner.DocOf
+func (prog *Package) DocCommentOf(node ast.Node) string {
+	if r := prog.u().lookupFile(node.Pos()); r != nil {
+		if doc := prog.u().commentScannerOf(prog.Fset, r.file).DocOf(node); doc != nil {
+			return StringifyCommentGroup(doc)
+		}
 	}
-	commentScanner := NewCommentScanner(prog.Fset, file)
-	doc := commentScanner.CommentsOf(node)
-	if doc != "" {
-		return doc
+	return ""
+}
+
+// LineCommentOf returns text of the trailing line comment of node, see CommentScanner.LineCommentOf
+func (prog *Package) LineCommentOf(node ast.Node) string {
+	if r := prog.u().lookupFile(node.Pos()); r != nil {
+		if comment := prog.u().commentScannerOf(prog.Fset, r.file).LineCommentOf(node); comment != nil {
+			return StringifyCommentGroup(comment)
+		}
 	}
-	return doc
+	return ""
 }
 
 func (prog *Package) Eval(expr ast.Expr) (types.TypeAndValue, error) {
@@ -178,9 +573,16 @@ func (prog *Package) Eval(expr ast.Expr) (types.TypeAndValue, error) {
 }
 
 func (prog *Package) FuncDeclOf(typeFunc *types.Func) (funcDecl *ast.FuncDecl) {
-	ast.Inspect(prog.FileOf(typeFunc), func(node ast.Node) bool {
+	p := prog.sourcePosOf(typeFunc)
+
+	file := prog.FileOf(pos(p))
+	if file == nil {
+		return nil
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
 		if decl, ok := node.(*ast.FuncDecl); ok {
-			if decl.Pos() <= typeFunc.Pos() && decl.Body != nil && typeFunc.Pos() < decl.Body.Pos() {
+			if decl.Pos() <= p && decl.Body != nil && p < decl.Body.Pos() {
 				funcDecl = decl
 				return false
 			}
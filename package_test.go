@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"go/format"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/go-courier/reflectx/typesutil"
 	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
 )
 
 func TestPackage(t *testing.T) {
@@ -33,6 +35,8 @@ func TestPackage(t *testing.T) {
 	{
 		tpeName := pkg.Const("A")
 		NewWithT(t).Expect(pkg.CommentsOf(pkg.IdentOf(tpeName))).To(Equal("a\n\nA"))
+		NewWithT(t).Expect(pkg.DocCommentOf(pkg.IdentOf(tpeName))).To(Equal("a"))
+		NewWithT(t).Expect(pkg.LineCommentOf(pkg.IdentOf(tpeName))).To(Equal("A"))
 	}
 
 	{
@@ -121,6 +125,25 @@ func TestPackage(t *testing.T) {
 				{`int`},
 			},
 		},
+		{
+			"FuncWithGenericCall",
+			[][]string{
+				{`int`},
+				{`github.com/go-courier/packagesx/__fixtures__.String`},
+			},
+		},
+		{
+			"FuncWithGenericListCall",
+			[][]string{
+				{`[]string`},
+			},
+		},
+		{
+			"FuncWithGenericMethodCall",
+			[][]string{
+				{`*github.com/go-courier/packagesx/__fixtures__.List[string]`},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -165,3 +188,222 @@ func printValues(fset *token.FileSet, results map[int][]TypeAndValueWithExpr) []
 
 	return s
 }
+
+func TestLoadWithConfig(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	pkgs, err := LoadWithConfig(&packages.Config{
+		Dir: cwd,
+	}, "./__fixtures__", "./__fixtures__/sub")
+
+	NewWithT(t).Expect(err).To(BeNil())
+	NewWithT(t).Expect(pkgs).To(HaveLen(2))
+
+	NewWithT(t).Expect(pkgs[0].Fset).To(BeIdenticalTo(pkgs[1].Fset))
+	NewWithT(t).Expect(pkgs[0].AllPackages).To(Equal(pkgs[1].AllPackages))
+
+	for _, pkg := range pkgs {
+		NewWithT(t).Expect(pkg.Pkg(pkg.PkgPath)).To(BeIdenticalTo(pkg.Package))
+
+		if pkg.Name == "sub" {
+			NewWithT(t).Expect(pkg.Func("CurryCall")).NotTo(BeNil())
+		}
+	}
+}
+
+func TestPackageScopedLookup(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	t.Run("package-level only", func(t *testing.T) {
+		NewWithT(t).Expect(pkg.Var("a")).To(BeNil())
+		NewWithT(t).Expect(pkg.Var("res")).To(BeNil())
+		NewWithT(t).Expect(pkg.Var("Int")).To(BeNil())
+		NewWithT(t).Expect(pkg.Var("test").Parent()).To(BeIdenticalTo(pkg.Types.Scope()))
+	})
+
+	t.Run("LocalVar", func(t *testing.T) {
+		res := pkg.LocalVar("fn", "res")
+		NewWithT(t).Expect(res).NotTo(BeNil())
+		NewWithT(t).Expect(res.Type().String()).To(Equal("string"))
+
+		param := pkg.LocalVar("Print", "b")
+		NewWithT(t).Expect(param).NotTo(BeNil())
+		NewWithT(t).Expect(pkg.IdentOf(param).Name).To(Equal("b"))
+
+		namedResult := pkg.LocalVar("FuncWillCall", "s")
+		NewWithT(t).Expect(namedResult.Type().String()).To(Equal("github.com/go-courier/packagesx/__fixtures__.String"))
+
+		NewWithT(t).Expect(pkg.LocalVar("fn", "unknown")).To(BeNil())
+		NewWithT(t).Expect(pkg.LocalVar("unknown", "res")).To(BeNil())
+	})
+
+	t.Run("Field", func(t *testing.T) {
+		field := pkg.Field("Test", "Int")
+		NewWithT(t).Expect(field).NotTo(BeNil())
+		NewWithT(t).Expect(field.IsField()).To(BeTrue())
+		NewWithT(t).Expect(pkg.CommentsOf(pkg.IdentOf(field))).To(Equal("field Int"))
+
+		NewWithT(t).Expect(pkg.Field("Test", "Recv")).To(BeNil())
+		NewWithT(t).Expect(pkg.Field("Test", "Unknown")).To(BeNil())
+	})
+}
+
+func TestPackageMethod(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	cases := []struct {
+		typeName   string
+		methodName string
+		results    [][]string
+	}{
+		{
+			"String", "Method",
+			[][]string{{"string"}},
+		},
+		{
+			"StringAlias", "Method",
+			[][]string{{"string"}},
+		},
+		{
+			"StringAlias", "PtrMethod",
+			[][]string{{`github.com/go-courier/packagesx/__fixtures__.String("ptr")`}},
+		},
+		{
+			"List", "Append",
+			[][]string{{"*github.com/go-courier/packagesx/__fixtures__.List[T]"}},
+		},
+		{
+			"List", "Len",
+			[][]string{{"int"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.typeName+"."+c.methodName, func(t *testing.T) {
+			method := pkg.Method(c.typeName, c.methodName)
+			NewWithT(t).Expect(method).NotTo(BeNil())
+			NewWithT(t).Expect(pkg.FuncDeclOf(method).Name.Name).To(Equal(c.methodName))
+
+			values, n := pkg.FuncResultsOf(method)
+			NewWithT(t).Expect(values).To(HaveLen(n))
+			NewWithT(t).Expect(printValues(pkg.Fset, values)).To(Equal(c.results))
+		})
+	}
+
+	NewWithT(t).Expect(pkg.Method("String", "Unknown")).To(BeNil())
+	NewWithT(t).Expect(pkg.Method("Test", "String")).To(BeNil())
+	NewWithT(t).Expect(pkg.Method("Unknown", "Method")).To(BeNil())
+}
+
+func TestPackageLookupQualified(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	cases := []struct {
+		qualifiedName string
+		expect        types.Object
+	}{
+		{"github.com/go-courier/packagesx/__fixtures__.String", pkg.TypeName("String")},
+		{"github.com/go-courier/packagesx/__fixtures__.String.Method", pkg.Method("String", "Method")},
+		{"github.com/go-courier/packagesx/__fixtures__.Test.Int", pkg.Field("Test", "Int")},
+		{"github.com/go-courier/packagesx/__fixtures__/sub.CurryCall", NewPackage(pkg.Pkg("github.com/go-courier/packagesx/__fixtures__/sub")).Func("CurryCall")},
+		{"strings.Join", NewPackage(pkg.Pkg("strings")).Func("Join")},
+		{"time.Time.Unix", NewPackage(pkg.Pkg("time")).Method("Time", "Unix")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.qualifiedName, func(t *testing.T) {
+			obj, err := pkg.LookupQualified(c.qualifiedName)
+			NewWithT(t).Expect(err).To(BeNil())
+			NewWithT(t).Expect(obj).NotTo(BeNil())
+			NewWithT(t).Expect(obj).To(BeIdenticalTo(c.expect))
+		})
+	}
+
+	errCases := []struct {
+		qualifiedName string
+		err           string
+	}{
+		{"github.com/x/y.Type", "package github.com/x/y of github.com/x/y.Type is not part of the loaded packages"},
+		{"github.com/go-courier/packagesx/__fixtures__.Unknown", "Unknown is not declared in package github.com/go-courier/packagesx/__fixtures__"},
+		{"github.com/go-courier/packagesx/__fixtures__.Print.X", "github.com/go-courier/packagesx/__fixtures__.Print is not a type"},
+		{"github.com/go-courier/packagesx/__fixtures__.Test.X", "github.com/go-courier/packagesx/__fixtures__.Test has no field or method X"},
+		{"Test", "invalid qualified name Test"},
+	}
+
+	for _, c := range errCases {
+		t.Run(c.qualifiedName, func(t *testing.T) {
+			_, err := pkg.LookupQualified(c.qualifiedName)
+			NewWithT(t).Expect(err).NotTo(BeNil())
+			NewWithT(t).Expect(err.Error()).To(Equal(c.err))
+		})
+	}
+}
+
+func TestPackageOrder(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	idsOf := func(pkgs []*packages.Package) []string {
+		ids := make([]string, len(pkgs))
+		for i := range pkgs {
+			ids[i] = pkgs[i].ID
+		}
+		return ids
+	}
+
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+	pkg2, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	NewWithT(t).Expect(idsOf(pkg.AllPackages)).To(Equal(idsOf(pkg2.AllPackages)))
+
+	t.Run("dependencies first", func(t *testing.T) {
+		indexes := map[string]int{}
+		for i, p := range pkg.AllPackages {
+			indexes[p.ID] = i
+		}
+		for i, p := range pkg.AllPackages {
+			for _, imported := range p.Imports {
+				NewWithT(t).Expect(indexes[imported.ID] < i).To(BeTrue())
+			}
+		}
+	})
+
+	t.Run("Walk", func(t *testing.T) {
+		sub := NewPackages(pkg.AllPackages...)[indexOfID(pkg.AllPackages, "github.com/go-courier/packagesx/__fixtures__/sub")]
+
+		visited := make([]*packages.Package, 0)
+		sub.Walk(func(p *packages.Package) bool {
+			visited = append(visited, p)
+			return true
+		})
+
+		NewWithT(t).Expect(idsOf(visited)).To(Equal([]string{"github.com/go-courier/packagesx/__fixtures__/sub"}))
+
+		visited = make([]*packages.Package, 0)
+		pkg.Walk(func(p *packages.Package) bool {
+			visited = append(visited, p)
+			return true
+		})
+
+		NewWithT(t).Expect(visited[len(visited)-1]).To(BeIdenticalTo(pkg.Package))
+		NewWithT(t).Expect(idsOf(visited)).To(ContainElement("strings"))
+
+		count := 0
+		pkg.Walk(func(p *packages.Package) bool {
+			count++
+			return count < 2
+		})
+		NewWithT(t).Expect(count).To(Equal(2))
+	})
+}
+
+func indexOfID(pkgs []*packages.Package, id string) int {
+	for i := range pkgs {
+		if pkgs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
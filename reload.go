@@ -0,0 +1,333 @@
+package packagesx
+
+import (
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Reload applies changedFiles as overlay of files, keyed by file path.
+// Packages containing changed files and their importers are parsed and type-checked again in place,
+// so *Package and *packages.Package returned before stay valid.
+// All packages will be loaded again by the build system when any changed file is not a part of loaded packages,
+// or imports of changed packages changed.
+func (prog *Package) Reload(changedFiles map[string][]byte) error {
+	return prog.reload(changedFiles, nil)
+}
+
+// reload applies changedFiles and removedFiles,
+// all packages will be loaded again when any file removed.
+func (prog *Package) reload(changedFiles map[string][]byte, removedFiles []string) error {
+	u := prog.u()
+
+	u.syntaxMu.Lock()
+	defer u.syntaxMu.Unlock()
+
+	changed := map[string]bool{}
+
+	for filename, content := range changedFiles {
+		filename, err := filepath.Abs(filename)
+		if err != nil {
+			return err
+		}
+		changed[filename] = true
+		u.overlay[filename] = content
+	}
+
+	if len(removedFiles) > 0 {
+		for _, filename := range removedFiles {
+			filename, err := filepath.Abs(filename)
+			if err != nil {
+				return err
+			}
+			delete(u.overlay, filename)
+		}
+		return u.reloadAll()
+	}
+
+	u.mu.Lock()
+	allPackages := u.allPackages
+	u.mu.Unlock()
+
+	// packages in topological order, affected when containing changed files or importing affected packages
+	affected := make([]*packages.Package, 0)
+	affectedSet := map[*packages.Package]bool{}
+	found := map[string]bool{}
+
+	for _, pkg := range allPackages {
+		isAffected := false
+
+		for _, filename := range pkg.CompiledGoFiles {
+			if changed[filename] {
+				found[filename] = true
+				isAffected = true
+			}
+		}
+
+		for _, imported := range pkg.Imports {
+			if affectedSet[imported] {
+				isAffected = true
+			}
+		}
+
+		if isAffected {
+			affectedSet[pkg] = true
+			affected = append(affected, pkg)
+		}
+	}
+
+	if len(found) < len(changed) {
+		return u.reloadAll()
+	}
+
+	parsed := make(map[*packages.Package][]*ast.File, len(affected))
+	parseErrors := make(map[*packages.Package][]packages.Error, len(affected))
+
+	for _, pkg := range affected {
+		if pkg.Fset == nil {
+			return u.reloadAll()
+		}
+
+		files, errors, err := u.parseFiles(pkg)
+		if err != nil {
+			return err
+		}
+
+		if !sameImports(pkg, files) {
+			return u.reloadAll()
+		}
+
+		parsed[pkg] = files
+		parseErrors[pkg] = errors
+	}
+
+	staleFiles := make([]*ast.File, 0)
+
+	for _, pkg := range affected {
+		tpkg, info, typeErrors := checkFiles(pkg, parsed[pkg])
+
+		errors := make([]packages.Error, 0)
+		for _, err := range pkg.Errors {
+			if err.Kind == packages.ListError {
+				errors = append(errors, err)
+			}
+		}
+		errors = append(errors, parseErrors[pkg]...)
+		for _, err := range typeErrors {
+			errors = append(errors, packages.Error{
+				Pos:  err.Fset.Position(err.Pos).String(),
+				Msg:  err.Msg,
+				Kind: packages.TypeError,
+			})
+		}
+
+		if s := u.syntaxOf(pkg); s != nil {
+			staleFiles = append(staleFiles, s.files...)
+		}
+
+		pkg.Syntax = parsed[pkg]
+		pkg.Types = tpkg
+		pkg.TypesInfo = info
+		pkg.Errors = errors
+		pkg.TypeErrors = typeErrors
+		pkg.IllTyped = len(errors) > 0
+
+		for _, imported := range pkg.Imports {
+			if imported.IllTyped {
+				pkg.IllTyped = true
+			}
+		}
+	}
+
+	u.mu.Lock()
+	u.fileIndex = u.fileIndex.remove(affectedSet)
+	for _, pkg := range affected {
+		// checked from source again, types and info of pkg are consistent now
+		delete(u.checked, pkg)
+		u.fileIndex = u.fileIndex.add(pkg, pkg.Syntax, pkg.Types, pkg.TypesInfo)
+		delete(u.symbolIndexes, pkg)
+	}
+	u.fileIndex = u.fileIndex.sorted()
+	for _, file := range staleFiles {
+		delete(u.commentScanners, file)
+	}
+	u.mu.Unlock()
+
+	u.resetFacts()
+
+	return nil
+}
+
+// snapshot returns a copy of prog with packages and universe of its own,
+// so reloading one of them does not change the other.
+func (prog *Package) snapshot() *Package {
+	u := prog.u()
+
+	directiveParser := u.directiveParserOf()
+
+	u.factsMu.Lock()
+	cacheDir := u.cacheDir
+	u.factsMu.Unlock()
+
+	// fields of packages are replaced with syntaxMu held
+	u.syntaxMu.Lock()
+	defer u.syntaxMu.Unlock()
+
+	u.mu.Lock()
+	allPackages := u.allPackages
+	u.mu.Unlock()
+
+	copies := make(map[*packages.Package]*packages.Package, len(allPackages))
+	for _, pkg := range allPackages {
+		c := *pkg
+		copies[pkg] = &c
+	}
+
+	list := make([]*packages.Package, len(allPackages))
+	for i, pkg := range allPackages {
+		c := copies[pkg]
+		c.Imports = make(map[string]*packages.Package, len(pkg.Imports))
+		for importPath, imported := range pkg.Imports {
+			c.Imports[importPath] = copies[imported]
+		}
+		list[i] = c
+	}
+
+	s := newUniverse(list)
+	s.config = u.config
+	s.patterns = u.patterns
+	s.matched = u.matched
+	s.cacheDir = cacheDir
+	s.directiveParser = directiveParser
+	for filename, content := range u.overlay {
+		s.overlay[filename] = content
+	}
+
+	root := &Package{
+		Package:     copies[prog.Package],
+		AllPackages: list,
+		universe:    s,
+	}
+	s.handles = []*Package{root}
+
+	return root
+}
+
+func sameImports(pkg *packages.Package, files []*ast.File) bool {
+	importPaths := map[string]bool{}
+
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return false
+			}
+			if importPath == "C" {
+				continue
+			}
+			importPaths[importPath] = true
+		}
+	}
+
+	for importPath := range pkg.Imports {
+		if !importPaths[importPath] {
+			return false
+		}
+	}
+
+	return len(importPaths) == len(pkg.Imports)
+}
+
+// reloadAll loads packages again with overlay,
+// packages of same ID are updated in place to keep references valid.
+func (u *universe) reloadAll() error {
+	if u.config == nil {
+		return fmt.Errorf("packages are not loaded by LoadWithConfig, only changes of loaded files could be reloaded")
+	}
+
+	c := *u.config
+	c.Overlay = map[string][]byte{}
+	for filename, content := range u.config.Overlay {
+		c.Overlay[filename] = content
+	}
+	for filename, content := range u.overlay {
+		c.Overlay[filename] = content
+	}
+
+	pkgs, err := packages.Load(&c, u.patterns...)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	olds := map[string]*packages.Package{}
+	for _, pkg := range u.allPackages {
+		olds[pkg.ID] = pkg
+	}
+
+	s := pkgSet{}
+	for _, pkg := range pkgs {
+		s.add(pkg)
+	}
+
+	loaded := s.allPackages()
+
+	pointerOf := func(pkg *packages.Package) *packages.Package {
+		if old, ok := olds[pkg.ID]; ok {
+			return old
+		}
+		return pkg
+	}
+
+	for _, pkg := range loaded {
+		imports := make(map[string]*packages.Package, len(pkg.Imports))
+		for importPath, imported := range pkg.Imports {
+			imports[importPath] = pointerOf(imported)
+		}
+		pkg.Imports = imports
+	}
+
+	allPackages := make([]*packages.Package, len(loaded))
+	for i, pkg := range loaded {
+		if old, ok := olds[pkg.ID]; ok {
+			*old = *pkg
+		}
+		allPackages[i] = pointerOf(pkg)
+	}
+
+	u.reset(allPackages)
+
+	for _, handle := range u.handles {
+		handle.AllPackages = allPackages
+	}
+
+	u.resetFacts()
+
+	return nil
+}
+
+// overlayOf returns content of filename applied by Reload or set in Overlay of config,
+// syntaxMu should be held.
+func (u *universe) overlayOf(filename string) ([]byte, bool) {
+	if content, ok := u.overlay[filename]; ok {
+		return content, true
+	}
+	if u.config != nil {
+		if content, ok := u.config.Overlay[filename]; ok {
+			return content, true
+		}
+	}
+	return nil, false
+}
+
+func (u *universe) resetFacts() {
+	u.factsMu.Lock()
+	defer u.factsMu.Unlock()
+
+	u.factsKeys = map[*packages.Package]string{}
+}
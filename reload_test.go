@@ -0,0 +1,109 @@
+package packagesx
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/reload\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "dep", "dep.go"), "package dep\n\nconst Value = 1\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package reload\n\nimport \"example.com/reload/dep\"\n\n// Value doc\nconst Value = dep.Value\n")
+
+	pkgs, err := LoadWithConfig(&packages.Config{Dir: dir}, ".")
+	NewWithT(t).Expect(err).To(BeNil())
+
+	pkg := pkgs[0]
+	loaded := pkg.Package
+	dep := pkg.Pkg("example.com/reload/dep")
+
+	t.Run("changes of package", func(t *testing.T) {
+		err := pkg.Reload(map[string][]byte{
+			filepath.Join(dir, "main.go"): []byte("package reload\n\nimport \"example.com/reload/dep\"\n\n// Value changed\nconst Value = dep.Value + 1\n"),
+		})
+		NewWithT(t).Expect(err).To(BeNil())
+
+		NewWithT(t).Expect(pkg.Package).To(BeIdenticalTo(loaded))
+		NewWithT(t).Expect(pkg.Const("Value").Val().String()).To(Equal("2"))
+		NewWithT(t).Expect(pkg.CommentsOf(pkg.IdentOf(pkg.Const("Value")))).To(Equal("Value changed"))
+		NewWithT(t).Expect(dep.Syntax).To(BeEmpty())
+	})
+
+	t.Run("changes of dependency", func(t *testing.T) {
+		err := pkg.Reload(map[string][]byte{
+			filepath.Join(dir, "dep", "dep.go"): []byte("package dep\n\nconst Value = 2\n"),
+		})
+		NewWithT(t).Expect(err).To(BeNil())
+
+		NewWithT(t).Expect(pkg.Pkg("example.com/reload/dep")).To(BeIdenticalTo(dep))
+		NewWithT(t).Expect(dep.Syntax).NotTo(BeEmpty())
+		NewWithT(t).Expect(pkg.Const("Value").Val().String()).To(Equal("3"))
+	})
+
+	t.Run("type errors", func(t *testing.T) {
+		err := pkg.Reload(map[string][]byte{
+			filepath.Join(dir, "dep", "dep.go"): []byte("package dep\n\nconst Value = \"2\"\n"),
+		})
+		NewWithT(t).Expect(err).To(BeNil())
+
+		NewWithT(t).Expect(pkg.IllTyped).To(BeTrue())
+		NewWithT(t).Expect(pkg.Err()).NotTo(BeNil())
+		NewWithT(t).Expect(pkg.Err().Error()).To(ContainSubstring("main.go:6"))
+	})
+
+	t.Run("new files", func(t *testing.T) {
+		err := pkg.Reload(map[string][]byte{
+			filepath.Join(dir, "dep", "dep.go"): []byte("package dep\n\nconst Value = 2\n"),
+			filepath.Join(dir, "extra.go"):      []byte("package reload\n\nconst Extra = Value\n"),
+		})
+		NewWithT(t).Expect(err).To(BeNil())
+
+		NewWithT(t).Expect(pkg.Package).To(BeIdenticalTo(loaded))
+		NewWithT(t).Expect(pkg.Err()).To(BeNil())
+		NewWithT(t).Expect(pkg.Const("Extra").Val().String()).To(Equal("3"))
+	})
+
+	t.Run("changes of imports", func(t *testing.T) {
+		err := pkg.Reload(map[string][]byte{
+			filepath.Join(dir, "main.go"): []byte("package reload\n\nimport \"strings\"\n\nconst Value = 1\n\nvar Join = strings.Join\n"),
+		})
+		NewWithT(t).Expect(err).To(BeNil())
+
+		NewWithT(t).Expect(pkg.Package).To(BeIdenticalTo(loaded))
+		NewWithT(t).Expect(pkg.Imports).To(HaveKey("strings"))
+		NewWithT(t).Expect(pkg.Imports).NotTo(HaveKey("example.com/reload/dep"))
+		NewWithT(t).Expect(pkg.Var("Join")).NotTo(BeNil())
+		NewWithT(t).Expect(pkg.AllPackages).To(ContainElement(pkg.Pkg("strings")))
+	})
+}
+
+func TestReloadWithConfigOverlay(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/reload\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "dep", "dep.go"), "package dep\n\nconst Value = 1\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package reload\n\nimport \"example.com/reload/dep\"\n\nconst Value = dep.Value\n")
+
+	pkgs, err := LoadWithConfig(&packages.Config{
+		Dir: dir,
+		Overlay: map[string][]byte{
+			filepath.Join(dir, "main.go"): []byte("package reload\n\nimport \"example.com/reload/dep\"\n\nconst Value = dep.Value\n\nconst FromOverlay = 1\n"),
+		},
+	}, ".")
+	NewWithT(t).Expect(err).To(BeNil())
+
+	pkg := pkgs[0]
+	NewWithT(t).Expect(pkg.Const("FromOverlay")).NotTo(BeNil())
+
+	NewWithT(t).Expect(pkg.Reload(map[string][]byte{
+		filepath.Join(dir, "dep", "dep.go"): []byte("package dep\n\nconst Value = 2\n"),
+	})).To(Succeed())
+
+	NewWithT(t).Expect(pkg.Const("Value").Val().String()).To(Equal("2"))
+	NewWithT(t).Expect(pkg.Const("FromOverlay")).NotTo(BeNil())
+}
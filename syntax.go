@@ -0,0 +1,230 @@
+package packagesx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/gcexportdata"
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultLoadMode loads typed syntax for root packages only.
+// Types of dependencies are loaded from export data,
+// and syntax of dependencies will be loaded on demand.
+const DefaultLoadMode = packages.LoadSyntax | packages.NeedModule | packages.NeedExportFile
+
+// ensureSyntax parses and type-checks pkg from source when it was loaded without syntax.
+// pkg.Types is kept, since objects of it are referenced by packages importing pkg.
+// Objects checked from source are not the ones of pkg.Types,
+// so the syntax, types and info checked are kept off pkg,
+// and recorded in u.checked and the file index only.
+func (u *universe) ensureSyntax(pkg *packages.Package) error {
+	u.syntaxMu.Lock()
+	defer u.syntaxMu.Unlock()
+
+	if u.syntaxOf(pkg) != nil {
+		return nil
+	}
+
+	if pkg.Fset == nil || len(pkg.CompiledGoFiles) == 0 {
+		return fmt.Errorf("package %s is loaded without files, NeedCompiledGoFiles and NeedTypes are required to load syntax on demand", pkg.ID)
+	}
+
+	files, _, err := u.parseFiles(pkg)
+	if err != nil {
+		return err
+	}
+
+	for _, imported := range pkg.Imports {
+		if err := u.ensureTypes(imported); err != nil {
+			return err
+		}
+	}
+
+	// errors of dependencies are ignored like the compiler did
+	tpkg, info, _ := checkFiles(pkg, files)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.checked[pkg] = &checkedSyntax{files: files, types: tpkg, info: info}
+	u.fileIndex = u.fileIndex.add(pkg, files, tpkg, info).sorted()
+	delete(u.symbolIndexes, pkg)
+
+	return nil
+}
+
+// ensureTypes completes pkg.Types from export data in place,
+// types of indirect dependencies are incomplete when loaded from export data of direct dependencies.
+// pkg.Types is shared, so it is read and written with u.mu held.
+func (u *universe) ensureTypes(pkg *packages.Package) error {
+	u.mu.Lock()
+	complete := pkg.Types != nil && pkg.Types.Complete()
+	u.mu.Unlock()
+
+	if complete {
+		return nil
+	}
+
+	if pkg.ExportFile == "" {
+		return fmt.Errorf("package %s is loaded without export data, NeedExportFile is required to load syntax on demand", pkg.ID)
+	}
+
+	f, err := os.Open(pkg.ExportFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := gcexportdata.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	view := map[string]*types.Package{}
+	for _, p := range u.allPackages {
+		if p.Types != nil {
+			view[p.PkgPath] = p.Types
+		}
+	}
+	u.mu.Unlock()
+
+	tpkg, err := gcexportdata.Read(r, pkg.Fset, view, pkg.PkgPath)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	pkg.Types = tpkg
+	u.mu.Unlock()
+
+	return nil
+}
+
+// parseFiles parses CompiledGoFiles of pkg, contents in overlay take precedence over files on disk,
+// syntaxMu should be held.
+func (u *universe) parseFiles(pkg *packages.Package) ([]*ast.File, []packages.Error, error) {
+	files := make([]*ast.File, 0, len(pkg.CompiledGoFiles))
+	errors := make([]packages.Error, 0)
+
+	for _, filename := range pkg.CompiledGoFiles {
+		var src interface{}
+		if content, ok := u.overlayOf(filename); ok {
+			src = content
+		}
+
+		file, err := parser.ParseFile(pkg.Fset, filename, src, parser.AllErrors|parser.ParseComments)
+		if file == nil {
+			return nil, nil, err
+		}
+
+		if list, ok := err.(scanner.ErrorList); ok {
+			for _, e := range list {
+				errors = append(errors, packages.Error{
+					Pos:  e.Pos.String(),
+					Msg:  e.Msg,
+					Kind: packages.ParseError,
+				})
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files, errors, nil
+}
+
+// checkFiles type-checks files of pkg with types of packages it imports.
+func checkFiles(pkg *packages.Package, files []*ast.File) (*types.Package, *types.Info, []types.Error) {
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Instances:  map[*ast.Ident]types.Instance{},
+		Scopes:     map[ast.Node]*types.Scope{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+
+	typeErrors := make([]types.Error, 0)
+
+	conf := &types.Config{
+		Importer: importerFunc(func(importPath string) (*types.Package, error) {
+			if importPath == "unsafe" {
+				return types.Unsafe, nil
+			}
+			if imported, ok := pkg.Imports[importPath]; ok && imported.Types != nil {
+				return imported.Types, nil
+			}
+			return nil, fmt.Errorf("package %s is not imported by %s", importPath, pkg.ID)
+		}),
+		Sizes: pkg.TypesSizes,
+		Error: func(err error) {
+			if typeErr, ok := err.(types.Error); ok {
+				typeErrors = append(typeErrors, typeErr)
+			}
+		},
+	}
+
+	if pkg.Module != nil && pkg.Module.GoVersion != "" {
+		conf.GoVersion = "go" + pkg.Module.GoVersion
+	}
+
+	tpkg, _ := conf.Check(pkg.PkgPath, pkg.Fset, files, info)
+
+	return tpkg, info, typeErrors
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+type pos token.Pos
+
+func (p pos) Pos() token.Pos {
+	return token.Pos(p)
+}
+
+// sourcePosOf returns pos of obj in syntax trees.
+// Syntax of the package of obj will be loaded when obj is loaded from export data,
+// which keeps file and line only.
+func (prog *Package) sourcePosOf(obj types.Object) token.Pos {
+	if prog.u().lookupFile(obj.Pos()) != nil || obj.Pkg() == nil {
+		return obj.Pos()
+	}
+
+	pkg := prog.declaringPkgOf(obj)
+	if pkg == nil || pkg.Fset == nil {
+		return obj.Pos()
+	}
+
+	if err := prog.u().ensureSyntax(pkg); err != nil {
+		return obj.Pos()
+	}
+
+	position := pkg.Fset.Position(obj.Pos())
+
+	for _, file := range prog.u().syntaxOf(pkg).files {
+		tokenFile := pkg.Fset.File(file.Pos())
+		if filepath.Base(tokenFile.Name()) != filepath.Base(position.Filename) {
+			continue
+		}
+		if position.Line < 1 || position.Line > tokenFile.LineCount() {
+			break
+		}
+		p := tokenFile.LineStart(position.Line)
+		if position.Column > 1 && tokenFile.Offset(p)+position.Column-1 <= tokenFile.Size() {
+			p += token.Pos(position.Column - 1)
+		}
+		return p
+	}
+
+	return obj.Pos()
+}
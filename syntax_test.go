@@ -0,0 +1,91 @@
+package packagesx
+
+import (
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestLoadSyntaxOnDemand(t *testing.T) {
+	cwd, _ := os.Getwd()
+	pkg, _ := Load(filepath.Join(cwd, "./__fixtures__"))
+
+	NewWithT(t).Expect(pkg.Syntax).NotTo(BeEmpty())
+
+	sub := pkg.Pkg("github.com/go-courier/packagesx/__fixtures__/sub")
+	NewWithT(t).Expect(sub.Syntax).To(BeEmpty())
+	NewWithT(t).Expect(pkg.Pkg("strings").Syntax).To(BeEmpty())
+
+	t.Run("FuncDeclOf", func(t *testing.T) {
+		curryCall := NewPackage(sub).Func("CurryCall")
+
+		funcDecl := pkg.FuncDeclOf(curryCall)
+		NewWithT(t).Expect(funcDecl).NotTo(BeNil())
+		NewWithT(t).Expect(funcDecl.Name.Name).To(Equal("CurryCall"))
+		NewWithT(t).Expect(pkg.FileOf(funcDecl)).NotTo(BeNil())
+
+		// syntax checked from source is kept off the package loaded from export data
+		NewWithT(t).Expect(sub.Syntax).To(BeEmpty())
+		NewWithT(t).Expect(sub.TypesInfo).To(BeNil())
+		NewWithT(t).Expect(sub.Types.Scope().Lookup("CurryCall")).To(BeIdenticalTo(curryCall))
+		NewWithT(t).Expect(pkg.PkgOf(funcDecl).Path()).To(Equal(sub.PkgPath))
+		NewWithT(t).Expect(pkg.PkgOf(funcDecl)).NotTo(BeIdenticalTo(sub.Types))
+
+		locatedPkg, info, file := pkg.Locate(funcDecl)
+		NewWithT(t).Expect(locatedPkg).To(BeIdenticalTo(sub))
+		NewWithT(t).Expect(info).NotTo(BeNil())
+		NewWithT(t).Expect(info.Defs[funcDecl.Name]).NotTo(BeNil())
+		NewWithT(t).Expect(file).NotTo(BeNil())
+	})
+
+	t.Run("FuncResultsOf", func(t *testing.T) {
+		values, n := pkg.FuncResultsOf(NewPackage(sub).Func("CurryCall"))
+		NewWithT(t).Expect(n).To(Equal(1))
+		NewWithT(t).Expect(printValues(pkg.Fset, values)).To(Equal([][]string{
+			{
+				"github.com/go-courier/packagesx/__fixtures__/sub.Func",
+				"github.com/go-courier/packagesx/__fixtures__/sub.Func",
+			},
+		}))
+	})
+
+	t.Run("CommentsOf", func(t *testing.T) {
+		join, _ := pkg.LookupQualified("strings.Join")
+
+		ident := pkg.IdentOf(join)
+		NewWithT(t).Expect(ident).NotTo(BeNil())
+		NewWithT(t).Expect(ident.Name).To(Equal("Join"))
+		NewWithT(t).Expect(pkg.CommentsOf(ident)).To(HavePrefix("Join concatenates"))
+
+		// indirect dependencies will be completed from export data
+		NewWithT(t).Expect(pkg.Pkg("unicode/utf8").Types.Complete()).To(BeTrue())
+	})
+
+	t.Run("methods", func(t *testing.T) {
+		unix, _ := pkg.LookupQualified("time.Time.Unix")
+		NewWithT(t).Expect(pkg.CommentsOf(pkg.FuncDeclOf(unix.(*types.Func)))).To(HavePrefix("Unix returns"))
+	})
+}
+
+func TestLoadAllSyntax(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	pkgs, err := LoadWithConfig(&packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Dir:  cwd,
+	}, "./__fixtures__")
+	NewWithT(t).Expect(err).To(BeNil())
+
+	pkg := pkgs[0]
+
+	sub := pkg.Pkg("github.com/go-courier/packagesx/__fixtures__/sub")
+	NewWithT(t).Expect(sub.Syntax).NotTo(BeEmpty())
+
+	curryCall := NewPackage(sub).Func("CurryCall")
+	NewWithT(t).Expect(pkg.IdentOf(curryCall).Pos()).To(Equal(curryCall.Pos()))
+	NewWithT(t).Expect(pkg.FuncDeclOf(curryCall).Name.Name).To(Equal("CurryCall"))
+}
@@ -9,9 +9,18 @@ import (
 	"strings"
 )
 
+// ImportGoPath strips the vendor prefix of importPath by the last vendor element,
+// only when the first element after it looks like a domain, like vendor/golang.org/x/net,
+// so vendor as a segment of path, like github.com/x/vendor/y, is kept.
+// Use Package.CanonicalImportPath to resolve by loaded packages.
 func ImportGoPath(importPath string) string {
-	parts := strings.Split(importPath, "/vendor/")
-	return parts[len(parts)-1]
+	if i := lastVendorElement(importPath); i >= 0 {
+		vendored := importPath[i+len("vendor/"):]
+		if strings.Contains(strings.SplitN(vendored, "/", 2)[0], ".") {
+			return vendored
+		}
+	}
+	return importPath
 }
 
 func GetPkgImportPathAndExpose(s string) (string, string) {
@@ -31,19 +40,42 @@ func StringifyNode(fset *token.FileSet, node ast.Node) string {
 	return buf.String()
 }
 
-func GetIdentChainOfCallFunc(expr ast.Expr) (list []*ast.Ident) {
+// GetIdentChainOfCallFunc returns idents of call chain of expr.
+// By syntax only, f[T](x) is an instantiation only when T is a type literal, like f[[]int](x),
+// since f[int](x) could be a call of element of slice or map too, use Package.IdentChainOfCallFunc for them.
+func GetIdentChainOfCallFunc(expr ast.Expr) []*ast.Ident {
+	return identChainOfCallFunc(expr, isTypeLit)
+}
+
+func identChainOfCallFunc(expr ast.Expr, isType func(index ast.Expr) bool) (list []*ast.Ident) {
 	switch e := expr.(type) {
 	case *ast.CallExpr:
-		list = append(list, GetIdentChainOfCallFunc(e.Fun)...)
+		list = append(list, identChainOfCallFunc(e.Fun, isType)...)
 	case *ast.SelectorExpr:
-		list = append(list, GetIdentChainOfCallFunc(e.X)...)
+		list = append(list, identChainOfCallFunc(e.X, isType)...)
 		list = append(list, e.Sel)
+	case *ast.IndexExpr:
+		// f[int](x)
+		if isType(e.Index) {
+			list = append(list, identChainOfCallFunc(e.X, isType)...)
+		}
+	case *ast.IndexListExpr:
+		// f[int, string](x)
+		list = append(list, identChainOfCallFunc(e.X, isType)...)
 	case *ast.Ident:
 		list = append(list, expr.(*ast.Ident))
 	}
 	return
 }
 
+func isTypeLit(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType, *ast.StructType:
+		return true
+	}
+	return false
+}
+
 func Deref(tpe types.Type) types.Type {
 	switch tpe.(type) {
 	case *types.Pointer:
@@ -1,6 +1,7 @@
 package packagesx
 
 import (
+	"go/parser"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -27,6 +28,26 @@ func TestGetPkgImportPathAndExpose(t *testing.T) {
 			"B",
 			"a.b.c.d/c.B",
 		},
+		{
+			"github.com/x/y",
+			"B",
+			"a.b.c.d/vendor/github.com/x/y.B",
+		},
+		{
+			"golang.org/x/net/http/httpguts",
+			"B",
+			"vendor/golang.org/x/net/http/httpguts.B",
+		},
+		{
+			"github.com/x/vendor",
+			"B",
+			"github.com/x/vendor.B",
+		},
+		{
+			"github.com/x/vendor/y",
+			"B",
+			"github.com/x/vendor/y.B",
+		},
 	}
 
 	for _, caseItem := range cases {
@@ -36,3 +57,30 @@ func TestGetPkgImportPathAndExpose(t *testing.T) {
 		NewWithT(t).Expect(expose).To(Equal(caseItem.expose))
 	}
 }
+
+func TestGetIdentChainOfCallFunc(t *testing.T) {
+	cases := map[string][]string{
+		"f(x)":                   {"f"},
+		"a.b.f(x)":               {"a", "b", "f"},
+		"f[int](x)":              {},
+		"f[[]int](x)":            {"f"},
+		"fns[0](x)":              {},
+		`m["k"](x)`:              {},
+		"a.f[int, string](x)":    {"a", "f"},
+		"a.New[int]().Append(x)": {"Append"},
+	}
+
+	for s, names := range cases {
+		expr, err := parser.ParseExpr(s)
+		NewWithT(t).Expect(err).To(BeNil())
+
+		idents := GetIdentChainOfCallFunc(expr)
+
+		list := make([]string, len(idents))
+		for i := range idents {
+			list[i] = idents[i].Name
+		}
+
+		NewWithT(t).Expect(list).To(Equal(names), s)
+	}
+}
@@ -0,0 +1,99 @@
+package packagesx
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Variant of package, when loaded with Tests enabled,
+// go/packages returns several variants of one package.
+type Variant int
+
+const (
+	// VariantProduction is the package without test files, ID "p"
+	VariantProduction Variant = iota
+	// VariantTest is the package with in-package test files, ID "p [p.test]"
+	VariantTest
+	// VariantXTest is the external test package, ID "p_test [p.test]"
+	VariantXTest
+	// VariantTestMain is the generated test main package, ID "p.test"
+	VariantTestMain
+	// VariantTestDependency is a dependency recompiled for the test of another package, ID "q [p.test]"
+	VariantTestDependency
+)
+
+func (v Variant) String() string {
+	switch v {
+	case VariantProduction:
+		return "production"
+	case VariantTest:
+		return "test"
+	case VariantXTest:
+		return "xtest"
+	case VariantTestMain:
+		return "test main"
+	case VariantTestDependency:
+		return "test dependency"
+	}
+	return "unknown"
+}
+
+func VariantOf(pkg *packages.Package) Variant {
+	id := pkg.ID
+
+	if i := strings.Index(id, " ["); i > 0 && strings.HasSuffix(id, "]") {
+		forTest := strings.TrimSuffix(id[i+2:len(id)-1], ".test")
+		path := id[0:i]
+
+		switch {
+		case path == forTest:
+			return VariantTest
+		case path == forTest+"_test":
+			return VariantXTest
+		}
+		return VariantTestDependency
+	}
+
+	if pkg.Name == "main" && strings.HasSuffix(id, ".test") {
+		return VariantTestMain
+	}
+
+	if strings.HasSuffix(pkg.Name, "_test") && strings.HasSuffix(pkg.PkgPath, "_test") {
+		return VariantXTest
+	}
+
+	return VariantProduction
+}
+
+// PkgVariant returns the variant of package importPath in AllPackages.
+// importPath is the import path of the package under test, without "_test" or ".test" suffix.
+func (prog *Package) PkgVariant(importPath string, variant Variant) *packages.Package {
+	for _, pkg := range prog.AllPackages {
+		if VariantOf(pkg) != variant {
+			continue
+		}
+		if variant != VariantTestDependency && packageUnderTest(pkg) == importPath {
+			return pkg
+		}
+		if variant == VariantTestDependency && pkg.PkgPath == importPath {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// ProductionPkg returns package importPath without test files
+func (prog *Package) ProductionPkg(importPath string) *packages.Package {
+	return prog.PkgVariant(importPath, VariantProduction)
+}
+
+// TestPkg returns package importPath compiled with its in-package test files
+func (prog *Package) TestPkg(importPath string) *packages.Package {
+	return prog.PkgVariant(importPath, VariantTest)
+}
+
+// XTestPkg returns the external test package importPath + "_test"
+func (prog *Package) XTestPkg(importPath string) *packages.Package {
+	return prog.PkgVariant(importPath, VariantXTest)
+}
@@ -0,0 +1,71 @@
+package packagesx
+
+import (
+	"go/ast"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestVariants(t *testing.T) {
+	cwd, _ := os.Getwd()
+
+	pkgs, err := LoadWithConfig(&packages.Config{Dir: cwd, Tests: true}, "./__fixtures__/variants")
+	NewWithT(t).Expect(err).To(BeNil())
+
+	importPath := "github.com/go-courier/packagesx/__fixtures__/variants"
+
+	variants := map[string]Variant{}
+	for _, pkg := range pkgs {
+		variants[pkg.ID] = VariantOf(pkg.Package)
+	}
+
+	NewWithT(t).Expect(variants).To(Equal(map[string]Variant{
+		importPath: VariantProduction,
+		importPath + " [" + importPath + ".test]":      VariantTest,
+		importPath + "_test [" + importPath + ".test]": VariantXTest,
+		importPath + ".test":                           VariantTestMain,
+	}))
+
+	prog := pkgs[0]
+
+	production := prog.ProductionPkg(importPath)
+	test := prog.TestPkg(importPath)
+	xtest := prog.XTestPkg(importPath)
+
+	NewWithT(t).Expect(production.ID).To(Equal(importPath))
+	NewWithT(t).Expect(test.ID).To(Equal(importPath + " [" + importPath + ".test]"))
+	NewWithT(t).Expect(xtest.ID).To(Equal(importPath + "_test [" + importPath + ".test]"))
+
+	t.Run("Pkg prefers production variant", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			NewWithT(t).Expect(prog.Pkg(importPath)).To(BeIdenticalTo(production))
+		}
+	})
+
+	t.Run("PkgOf and FileOf resolve files to variant", func(t *testing.T) {
+		internalValue := test.Types.Scope().Lookup("InternalValue")
+		NewWithT(t).Expect(prog.PkgOf(&ast.Ident{NamePos: internalValue.Pos()})).To(BeIdenticalTo(test.Types))
+		NewWithT(t).Expect(prog.FileOf(&ast.Ident{NamePos: internalValue.Pos()})).NotTo(BeNil())
+
+		externalValue := xtest.Types.Scope().Lookup("ExternalValue")
+		NewWithT(t).Expect(prog.PkgOf(&ast.Ident{NamePos: externalValue.Pos()})).To(BeIdenticalTo(xtest.Types))
+
+		value := production.Types.Scope().Lookup("Value")
+		NewWithT(t).Expect(prog.PkgOf(&ast.Ident{NamePos: value.Pos()})).To(BeIdenticalTo(production.Types))
+	})
+
+	t.Run("IdentOf objects of test variants", func(t *testing.T) {
+		internalValue := test.Types.Scope().Lookup("InternalValue")
+		NewWithT(t).Expect(prog.IdentOf(internalValue)).NotTo(BeNil())
+		NewWithT(t).Expect(prog.DocOfObject(internalValue).Text).To(Equal("InternalValue only exists in test variant"))
+
+		externalValue := xtest.Types.Scope().Lookup("ExternalValue")
+		NewWithT(t).Expect(prog.CommentsOf(prog.IdentOf(externalValue))).To(Equal("ExternalValue only exists in external test package"))
+
+		testValue := test.Types.Scope().Lookup("Value")
+		NewWithT(t).Expect(prog.IdentOf(testValue).Pos()).To(Equal(testValue.Pos()))
+	})
+}
@@ -0,0 +1,214 @@
+package packagesx
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// VendorInfo of package loaded from a vendor directory
+type VendorInfo struct {
+	// ImportPath is the canonical import path, like golang.org/x/net/http/httpguts
+	ImportPath string
+	// VendoredPath is the import path with vendor prefix, like vendor/golang.org/x/net/http/httpguts
+	VendoredPath string
+	// Module which the package vendored from, nil when not listed in vendor/modules.txt
+	Module *packages.Module
+}
+
+// VendorInfoOf returns nil when pkg is not loaded from a vendor directory.
+func (prog *Package) VendorInfoOf(pkg *packages.Package) *VendorInfo {
+	if pkg == nil {
+		return nil
+	}
+	return prog.vendorIndex().infos[pkg]
+}
+
+// CanonicalImportPath returns the canonical import path of a loaded package,
+// importPath could be in canonical or vendored form.
+// Import paths of packages not loaded are returned as is.
+func (prog *Package) CanonicalImportPath(importPath string) string {
+	if pkg := prog.Pkg(importPath); pkg != nil {
+		if info := prog.VendorInfoOf(pkg); info != nil {
+			return info.ImportPath
+		}
+		return pkg.PkgPath
+	}
+	return importPath
+}
+
+// PkgImportPathAndExpose works like GetPkgImportPathAndExpose,
+// but the import path is resolved by CanonicalImportPath when the package is loaded.
+func (prog *Package) PkgImportPathAndExpose(s string) (string, string) {
+	importPath, expose := GetPkgImportPathAndExpose(s)
+	if importPath == "" {
+		return importPath, expose
+	}
+
+	vendoredPath := s[0 : len(s)-len(expose)-1]
+	if prog.Pkg(vendoredPath) != nil {
+		return prog.CanonicalImportPath(vendoredPath), expose
+	}
+
+	return importPath, expose
+}
+
+type vendorIndex struct {
+	infos map[*packages.Package]*VendorInfo
+	// pkgs by import path in the form other than pkg.PkgPath
+	pkgs map[string]*packages.Package
+}
+
+func (prog *Package) vendorIndex() *vendorIndex {
+	u := prog.u()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.vendorIndex == nil {
+		u.vendorIndex = newVendorIndex(u.allPackages)
+	}
+	return u.vendorIndex
+}
+
+func newVendorIndex(allPackages []*packages.Package) *vendorIndex {
+	idx := &vendorIndex{
+		infos: map[*packages.Package]*VendorInfo{},
+		pkgs:  map[string]*packages.Package{},
+	}
+
+	mainModules := map[string]string{}
+	for _, pkg := range allPackages {
+		if pkg.Module != nil && pkg.Module.Main {
+			mainModules[filepath.ToSlash(pkg.Module.Dir)] = pkg.Module.Path
+		}
+	}
+
+	vendorModules := map[string]map[string]*packages.Module{}
+
+	for _, pkg := range allPackages {
+		dir := filepath.ToSlash(pkg.Dir)
+
+		info := vendorInfoOf(pkg, mainModules)
+		if info == nil {
+			continue
+		}
+
+		if info.Module == nil {
+			vendorDir := filepath.FromSlash(strings.TrimSuffix(dir, "/"+info.ImportPath))
+			if _, ok := vendorModules[vendorDir]; !ok {
+				vendorModules[vendorDir] = readVendorModules(filepath.Join(vendorDir, "modules.txt"))
+			}
+			info.Module = vendorModules[vendorDir][info.ImportPath]
+		}
+
+		idx.infos[pkg] = info
+
+		for _, importPath := range []string{info.ImportPath, info.VendoredPath} {
+			if importPath != pkg.PkgPath {
+				if _, ok := idx.pkgs[importPath]; !ok {
+					idx.pkgs[importPath] = pkg
+				}
+			}
+		}
+	}
+
+	return idx
+}
+
+func vendorInfoOf(pkg *packages.Package, mainModules map[string]string) *VendorInfo {
+	if pkg.Dir == "" || pkg.PkgPath == "" {
+		return nil
+	}
+
+	dir := filepath.ToSlash(pkg.Dir)
+
+	// GOPATH mode and vendor of std, pkg path contains vendor element
+	if i := lastVendorElement(pkg.PkgPath); i >= 0 {
+		if strings.HasSuffix(dir, "/"+pkg.PkgPath) {
+			return &VendorInfo{
+				ImportPath:   pkg.PkgPath[i+len("vendor/"):],
+				VendoredPath: pkg.PkgPath,
+			}
+		}
+		return nil
+	}
+
+	// module mode with -mod=vendor, pkg path is canonical, but dir is in vendor of main module
+	if root := strings.TrimSuffix(dir, "/vendor/"+pkg.PkgPath); root != dir {
+		vendoredPath := "vendor/" + pkg.PkgPath
+		if modulePath, ok := mainModules[root]; ok {
+			vendoredPath = path.Join(modulePath, vendoredPath)
+		}
+		return &VendorInfo{
+			ImportPath:   pkg.PkgPath,
+			VendoredPath: vendoredPath,
+			Module:       pkg.Module,
+		}
+	}
+
+	return nil
+}
+
+// lastVendorElement returns index of the last vendor element of importPath, or -1
+func lastVendorElement(importPath string) int {
+	if i := strings.LastIndex(importPath, "/vendor/"); i >= 0 {
+		return i + 1
+	}
+	if strings.HasPrefix(importPath, "vendor/") {
+		return 0
+	}
+	return -1
+}
+
+// readVendorModules reads modules by package paths from vendor/modules.txt
+func readVendorModules(filename string) map[string]*packages.Module {
+	modules := map[string]*packages.Module{}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return modules
+	}
+	defer f.Close()
+
+	var module *packages.Module
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "## "):
+		case strings.HasPrefix(line, "# "):
+			// # path version [=> replacement [version]]
+			fields := strings.Fields(line[2:])
+			module = &packages.Module{Path: fields[0]}
+			if len(fields) > 1 && fields[1] != "=>" {
+				module.Version = fields[1]
+			}
+			if i := indexOf(fields, "=>"); i >= 0 && i+1 < len(fields) {
+				module.Replace = &packages.Module{Path: fields[i+1]}
+				if i+2 < len(fields) {
+					module.Replace.Version = fields[i+2]
+				}
+			}
+		case line != "" && module != nil:
+			modules[line] = module
+		}
+	}
+
+	return modules
+}
+
+func indexOf(list []string, s string) int {
+	for i := range list {
+		if list[i] == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,73 @@
+package packagesx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestVendor(t *testing.T) {
+	t.Run("module vendor mode", func(t *testing.T) {
+		dir := t.TempDir()
+
+		writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/app\n\ngo 1.22\n\nrequire example.com/dep v1.2.0\n")
+		writeFile(t, filepath.Join(dir, "vendor", "modules.txt"), "# example.com/dep v1.2.0\n## explicit\nexample.com/dep\n")
+		writeFile(t, filepath.Join(dir, "vendor", "example.com", "dep", "dep.go"), "package dep\n\ntype Dep struct{ Name string }\n")
+		writeFile(t, filepath.Join(dir, "app.go"), "package app\n\nimport \"example.com/dep\"\n\nvar D = dep.Dep{}\n")
+
+		pkgs, err := LoadWithConfig(&packages.Config{
+			Dir: dir,
+			Env: append(os.Environ(), "GOFLAGS=-mod=vendor"),
+		}, ".")
+		NewWithT(t).Expect(err).To(BeNil())
+
+		prog := pkgs[0]
+
+		dep := prog.Pkg("example.com/dep")
+		NewWithT(t).Expect(dep).NotTo(BeNil())
+		NewWithT(t).Expect(prog.Pkg("example.com/app/vendor/example.com/dep")).To(BeIdenticalTo(dep))
+
+		info := prog.VendorInfoOf(dep)
+		NewWithT(t).Expect(info.ImportPath).To(Equal("example.com/dep"))
+		NewWithT(t).Expect(info.VendoredPath).To(Equal("example.com/app/vendor/example.com/dep"))
+		NewWithT(t).Expect(info.Module.Version).To(Equal("v1.2.0"))
+		NewWithT(t).Expect(prog.VendorInfoOf(prog.Package)).To(BeNil())
+
+		NewWithT(t).Expect(prog.CanonicalImportPath("example.com/app/vendor/example.com/dep")).To(Equal("example.com/dep"))
+		NewWithT(t).Expect(prog.ModuleVersion("example.com/app/vendor/example.com/dep")).To(Equal("v1.2.0"))
+
+		importPath, expose := prog.PkgImportPathAndExpose("example.com/app/vendor/example.com/dep.Dep")
+		NewWithT(t).Expect(importPath).To(Equal("example.com/dep"))
+		NewWithT(t).Expect(expose).To(Equal("Dep"))
+
+		importPath, _ = prog.PkgImportPathAndExpose("example.com/app.D")
+		NewWithT(t).Expect(importPath).To(Equal("example.com/app"))
+
+		obj, err := prog.LookupQualified("example.com/app/vendor/example.com/dep.Dep.Name")
+		NewWithT(t).Expect(err).To(BeNil())
+		NewWithT(t).Expect(obj.Pkg().Path()).To(Equal("example.com/dep"))
+	})
+
+	t.Run("vendor of std", func(t *testing.T) {
+		prog, err := Load("net/http")
+		NewWithT(t).Expect(err).To(BeNil())
+
+		canonical := "golang.org/x/net/http/httpguts"
+
+		pkg := prog.Pkg(canonical)
+		NewWithT(t).Expect(pkg).NotTo(BeNil())
+		NewWithT(t).Expect(pkg.PkgPath).To(Equal("vendor/" + canonical))
+		NewWithT(t).Expect(prog.CanonicalImportPath(pkg.PkgPath)).To(Equal(canonical))
+
+		m := prog.ModuleOf(canonical)
+		NewWithT(t).Expect(m).NotTo(BeNil())
+		NewWithT(t).Expect(m.Path).To(Equal("golang.org/x/net"))
+		NewWithT(t).Expect(m.Version).NotTo(BeEmpty())
+
+		_, err = prog.LookupQualified(canonical + ".ValidHeaderFieldName")
+		NewWithT(t).Expect(err).To(BeNil())
+	})
+}
@@ -0,0 +1,331 @@
+package packagesx
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EventKind int
+
+const (
+	EventUnknown EventKind = iota
+	// EventPackageReloaded when files of package changed
+	EventPackageReloaded
+	EventSymbolAdded
+	EventSymbolRemoved
+	// EventSymbolChanged when kind, type or value of symbol changed
+	EventSymbolChanged
+	// EventDocChanged when only doc comment of symbol changed
+	EventDocChanged
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventPackageReloaded:
+		return "package reloaded"
+	case EventSymbolAdded:
+		return "symbol added"
+	case EventSymbolRemoved:
+		return "symbol removed"
+	case EventSymbolChanged:
+		return "symbol changed"
+	case EventDocChanged:
+		return "doc changed"
+	}
+	return "unknown"
+}
+
+type Event struct {
+	Kind    EventKind
+	PkgPath string
+	// Symbol is the name of package-level object, or Type.Method for methods.
+	// Empty for EventPackageReloaded
+	Symbol string
+}
+
+// Watcher polls directories of module-local packages,
+// reloads packages when files changed, and emits events of changes.
+// Packages are reloaded on a copy of prog, prog is never changed by Run,
+// reloaded packages are delivered by Snapshot.
+type Watcher struct {
+	// Interval of polling, 500ms by default
+	Interval time.Duration
+	// Debounce waits for files not changed in the duration before reloading, 200ms by default
+	Debounce time.Duration
+
+	prog   *Package
+	events chan Event
+	errors chan error
+	ready  chan struct{}
+
+	mu       sync.Mutex
+	snapshot *Package
+}
+
+func NewWatcher(prog *Package) *Watcher {
+	return &Watcher{
+		Interval: 500 * time.Millisecond,
+		Debounce: 200 * time.Millisecond,
+		prog:     prog,
+		events:   make(chan Event, 64),
+		errors:   make(chan error, 8),
+		ready:    make(chan struct{}),
+		snapshot: prog,
+	}
+}
+
+// Snapshot returns packages of the last reload, or prog before any reload.
+// Snapshot is taken before events of the reload sent, and never changed by Run,
+// so it is safe to read while Run is active.
+func (w *Watcher) Snapshot() *Package {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.snapshot
+}
+
+// Ready will be closed when Run took the first snapshot of files,
+// changes after that will be watched.
+func (w *Watcher) Ready() <-chan struct{} {
+	return w.ready
+}
+
+// Events will be closed when Run returned
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Errors of reloading, will be closed when Run returned
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Run watches until ctx done.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+	defer close(w.errors)
+
+	prog := w.prog.snapshot()
+
+	files := scan(prog)
+	symbols := symbolsOf(prog)
+
+	close(w.ready)
+
+	pending := map[string]bool{}
+	lastChanged := time.Time{}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			next := scan(prog)
+
+			for filename, sum := range next {
+				if files[filename] != sum {
+					pending[filename] = true
+					lastChanged = now
+				}
+			}
+			for filename := range files {
+				if _, ok := next[filename]; !ok {
+					pending[filename] = true
+					lastChanged = now
+				}
+			}
+
+			files = next
+
+			if len(pending) == 0 || now.Sub(lastChanged) < w.Debounce {
+				continue
+			}
+
+			changed := map[string][]byte{}
+			removed := make([]string, 0)
+
+			for filename := range pending {
+				content, err := os.ReadFile(filename)
+				if err != nil {
+					removed = append(removed, filename)
+					continue
+				}
+				changed[filename] = content
+			}
+
+			pending = map[string]bool{}
+
+			if err := prog.reload(changed, removed); err != nil {
+				select {
+				case w.errors <- err:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				continue
+			}
+
+			nextSymbols := symbolsOf(prog)
+
+			w.mu.Lock()
+			w.snapshot = prog.snapshot()
+			w.mu.Unlock()
+
+			for _, e := range diffSymbols(symbols, nextSymbols, dirsOf(changed, removed)) {
+				select {
+				case w.events <- e:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			symbols = nextSymbols
+		}
+	}
+}
+
+// modulePackages returns wrapped module-local packages in AllPackages,
+// wrapped again on each call, since AllPackages could change when reloaded.
+func modulePackages(prog *Package) []*Package {
+	list := make([]*Package, 0)
+	for _, pkg := range prog.AllPackages {
+		if PackageKindOf(pkg) == PackageKindModule && pkg.Dir != "" {
+			list = append(list, prog.wrap(pkg))
+		}
+	}
+	return list
+}
+
+// scan returns sum of go files in directories of module-local packages
+func scan(prog *Package) map[string][sha256.Size]byte {
+	files := map[string][sha256.Size]byte{}
+
+	for _, pkg := range modulePackages(prog) {
+		entries, err := os.ReadDir(pkg.Dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+				continue
+			}
+			filename := filepath.Join(pkg.Dir, entry.Name())
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				continue
+			}
+			files[filename] = sha256.Sum256(content)
+		}
+	}
+
+	return files
+}
+
+type packageSymbols struct {
+	dir     string
+	symbols map[string]SymbolFacts
+}
+
+func symbolsOf(prog *Package) map[string]packageSymbols {
+	all := map[string]packageSymbols{}
+
+	for _, pkg := range modulePackages(prog) {
+		if pkg.Types == nil {
+			continue
+		}
+
+		s := packageSymbols{
+			dir:     pkg.Dir,
+			symbols: map[string]SymbolFacts{},
+		}
+
+		for _, symbol := range pkg.symbolFacts(false) {
+			s.symbols[symbol.Name] = symbol
+		}
+
+		all[pkg.PkgPath] = s
+	}
+
+	return all
+}
+
+func dirsOf(changed map[string][]byte, removed []string) map[string]bool {
+	dirs := map[string]bool{}
+	for filename := range changed {
+		dirs[filepath.Dir(filename)] = true
+	}
+	for _, filename := range removed {
+		dirs[filepath.Dir(filename)] = true
+	}
+	return dirs
+}
+
+// diffSymbols returns events in order of package path and symbol name.
+func diffSymbols(prev map[string]packageSymbols, next map[string]packageSymbols, changedDirs map[string]bool) []Event {
+	pkgPaths := make([]string, 0, len(next))
+	for pkgPath := range next {
+		pkgPaths = append(pkgPaths, pkgPath)
+	}
+	sort.Strings(pkgPaths)
+
+	events := make([]Event, 0)
+
+	for _, pkgPath := range pkgPaths {
+		before, after := prev[pkgPath].symbols, next[pkgPath].symbols
+
+		symbolEvents := make([]Event, 0)
+
+		for _, name := range sortedSymbolNames(before, after) {
+			b, inBefore := before[name]
+			a, inAfter := after[name]
+
+			kind := EventUnknown
+
+			switch {
+			case !inBefore:
+				kind = EventSymbolAdded
+			case !inAfter:
+				kind = EventSymbolRemoved
+			case b.Kind != a.Kind || b.Type != a.Type || b.Value != a.Value:
+				kind = EventSymbolChanged
+			case b.Doc != a.Doc:
+				kind = EventDocChanged
+			}
+
+			if kind != EventUnknown {
+				symbolEvents = append(symbolEvents, Event{Kind: kind, PkgPath: pkgPath, Symbol: name})
+			}
+		}
+
+		if changedDirs[next[pkgPath].dir] || len(symbolEvents) > 0 {
+			events = append(events, Event{Kind: EventPackageReloaded, PkgPath: pkgPath})
+		}
+
+		events = append(events, symbolEvents...)
+	}
+
+	return events
+}
+
+func sortedSymbolNames(maps ...map[string]SymbolFacts) []string {
+	set := map[string]bool{}
+	for _, m := range maps {
+		for name := range m {
+			set[name] = true
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,125 @@
+package packagesx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/watch\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "dep", "dep.go"), "package dep\n\n// Value doc\nconst Value = 1\n\nconst Removed = 1\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package watch\n\nimport \"example.com/watch/dep\"\n\nconst Value = dep.Value\n")
+
+	pkgs, err := LoadWithConfig(&packages.Config{Dir: dir}, ".")
+	NewWithT(t).Expect(err).To(BeNil())
+
+	pkg := pkgs[0]
+	handles := len(pkg.u().handles)
+
+	w := NewWatcher(pkg)
+	w.Interval = 10 * time.Millisecond
+	w.Debounce = 30 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	// packages could be read while watching
+	reading := make(chan struct{})
+	go func() {
+		defer close(reading)
+		for ctx.Err() == nil {
+			_ = pkg.Const("Value").Val()
+			_ = w.Snapshot().Const("Value")
+		}
+	}()
+
+	select {
+	case <-w.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for watcher ready")
+	}
+
+	writeFile(t, filepath.Join(dir, "dep", "dep.go"), "package dep\n\n// Value changed\nconst Value = 2\n\nconst Added = 1\n")
+
+	NewWithT(t).Expect(receiveEvents(t, w, 6)).To(Equal([]Event{
+		{Kind: EventPackageReloaded, PkgPath: "example.com/watch"},
+		{Kind: EventSymbolChanged, PkgPath: "example.com/watch", Symbol: "Value"},
+		{Kind: EventPackageReloaded, PkgPath: "example.com/watch/dep"},
+		{Kind: EventSymbolAdded, PkgPath: "example.com/watch/dep", Symbol: "Added"},
+		{Kind: EventSymbolRemoved, PkgPath: "example.com/watch/dep", Symbol: "Removed"},
+		{Kind: EventSymbolChanged, PkgPath: "example.com/watch/dep", Symbol: "Value"},
+	}))
+
+	NewWithT(t).Expect(w.Snapshot().Const("Value").Val().String()).To(Equal("2"))
+	NewWithT(t).Expect(pkg.Const("Value").Val().String()).To(Equal("1"))
+
+	t.Run("doc changed", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "dep", "dep.go"), "package dep\n\n// Value doc changed\nconst Value = 2\n\nconst Added = 1\n")
+
+		NewWithT(t).Expect(receiveEvents(t, w, 2)).To(Equal([]Event{
+			{Kind: EventPackageReloaded, PkgPath: "example.com/watch/dep"},
+			{Kind: EventDocChanged, PkgPath: "example.com/watch/dep", Symbol: "Value"},
+		}))
+	})
+
+	t.Run("removed files", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "extra.go"), "package watch\n\nconst Extra = 1\n")
+
+		NewWithT(t).Expect(receiveEvents(t, w, 2)).To(Equal([]Event{
+			{Kind: EventPackageReloaded, PkgPath: "example.com/watch"},
+			{Kind: EventSymbolAdded, PkgPath: "example.com/watch", Symbol: "Extra"},
+		}))
+
+		NewWithT(t).Expect(os.Remove(filepath.Join(dir, "extra.go"))).To(Succeed())
+
+		NewWithT(t).Expect(receiveEvents(t, w, 2)).To(Equal([]Event{
+			{Kind: EventPackageReloaded, PkgPath: "example.com/watch"},
+			{Kind: EventSymbolRemoved, PkgPath: "example.com/watch", Symbol: "Extra"},
+		}))
+	})
+
+	cancel()
+
+	select {
+	case err := <-done:
+		NewWithT(t).Expect(err).To(Equal(context.Canceled))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for watcher stopped")
+	}
+	<-reading
+	NewWithT(t).Expect(pkg.u().handles).To(HaveLen(handles))
+	_, ok := <-w.Events()
+	NewWithT(t).Expect(ok).To(BeFalse())
+}
+
+func receiveEvents(t *testing.T, w *Watcher, n int) []Event {
+	events := make([]Event, 0, n)
+
+	timeout := time.After(5 * time.Second)
+
+	for len(events) < n {
+		select {
+		case e := <-w.Events():
+			events = append(events, e)
+		case err := <-w.Errors():
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatalf("timeout, got %v", events)
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,171 @@
+package packagesx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LoadWorkspace loads patterns of modules in the go.work workspace which contains cfg.Dir.
+// Patterns could be from several modules, like "./a/...", "./b/...".
+// -mod in GOFLAGS is dropped, since it is not allowed in workspace mode.
+func LoadWorkspace(cfg *packages.Config, patterns ...string) ([]*Package, error) {
+	c := packages.Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+
+	env := c.Env
+	if env == nil {
+		env = os.Environ()
+	}
+
+	goWork := lookupEnv(env, "GOWORK")
+
+	if goWork == "" {
+		dir := c.Dir
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		goWork = findGoWork(dir)
+		if goWork == "" {
+			return nil, fmt.Errorf("go.work not found in %s or any parent directory", dir)
+		}
+	}
+
+	if goWork == "off" {
+		return nil, fmt.Errorf("workspace mode is disabled by GOWORK=off")
+	}
+
+	c.Env = append(withoutModFlag(env), "GOWORK="+goWork)
+
+	return LoadWithConfig(&c, patterns...)
+}
+
+func findGoWork(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		goWork := filepath.Join(dir, "go.work")
+		if info, err := os.Stat(goWork); err == nil && !info.IsDir() {
+			return goWork
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// lookupEnv returns the last value of key in env
+func lookupEnv(env []string, key string) string {
+	value := ""
+	for _, kv := range env {
+		if strings.HasPrefix(kv, key+"=") {
+			value = kv[len(key)+1:]
+		}
+	}
+	return value
+}
+
+func withoutModFlag(env []string) []string {
+	list := make([]string, 0, len(env))
+
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "GOFLAGS=") {
+			flags := make([]string, 0)
+			for _, flag := range strings.Fields(kv[len("GOFLAGS="):]) {
+				if !strings.HasPrefix(flag, "-mod=") && !strings.HasPrefix(flag, "--mod=") {
+					flags = append(flags, flag)
+				}
+			}
+			kv = "GOFLAGS=" + strings.Join(flags, " ")
+		}
+		list = append(list, kv)
+	}
+
+	return list
+}
+
+// Modules returns modules of all packages in AllPackages, sorted by path.
+// Packages of std have no module.
+func (prog *Package) Modules() []*packages.Module {
+	modules := map[string]*packages.Module{}
+
+	for _, pkg := range prog.AllPackages {
+		if pkg.Module != nil {
+			modules[pkg.Module.Path] = pkg.Module
+		}
+	}
+
+	list := make([]*packages.Module, 0, len(modules))
+	for _, m := range modules {
+		list = append(list, m)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+
+	return list
+}
+
+// ModuleOf returns the module of package pkgPath.
+// When package pkgPath is not loaded, returns the loaded module with the longest path prefix of pkgPath.
+func (prog *Package) ModuleOf(pkgPath string) *packages.Module {
+	if pkg := prog.Pkg(pkgPath); pkg != nil {
+		if pkg.Module == nil {
+			if info := prog.VendorInfoOf(pkg); info != nil {
+				return info.Module
+			}
+		}
+		return pkg.Module
+	}
+
+	var found *packages.Module
+
+	for _, m := range prog.Modules() {
+		if pkgPath == m.Path || strings.HasPrefix(pkgPath, m.Path+"/") {
+			if found == nil || len(m.Path) > len(found.Path) {
+				found = m
+			}
+		}
+	}
+
+	return found
+}
+
+// ModuleRoot returns the root dir of module of package pkgPath
+func (prog *Package) ModuleRoot(pkgPath string) string {
+	m := prog.ModuleOf(pkgPath)
+	if m == nil {
+		return ""
+	}
+	if m.Replace != nil && m.Replace.Dir != "" {
+		return m.Replace.Dir
+	}
+	return m.Dir
+}
+
+// ModuleVersion returns the version of module of package pkgPath,
+// the version of replacement when replaced.
+// Main modules and modules of workspace have empty version.
+func (prog *Package) ModuleVersion(pkgPath string) string {
+	m := prog.ModuleOf(pkgPath)
+	if m == nil {
+		return ""
+	}
+	if m.Replace != nil {
+		return m.Replace.Version
+	}
+	return m.Version
+}
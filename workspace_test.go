@@ -0,0 +1,50 @@
+package packagesx
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestLoadWorkspace(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "go.work"), "go 1.22\n\nuse (\n\t./a\n\t./b\n)\n")
+	writeFile(t, filepath.Join(dir, "a", "go.mod"), "module example.com/a\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "a", "a.go"), "package a\n\ntype A struct{ Name string }\n")
+	writeFile(t, filepath.Join(dir, "b", "go.mod"), "module example.com/b\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(dir, "b", "sub", "b.go"), "package sub\n\nimport \"example.com/a\"\n\ntype B struct{ a.A }\n")
+
+	pkgs, err := LoadWorkspace(&packages.Config{Dir: filepath.Join(dir, "b")}, "example.com/a/...", "example.com/b/...")
+	NewWithT(t).Expect(err).To(BeNil())
+	NewWithT(t).Expect(pkgs).To(HaveLen(2))
+
+	prog := pkgs[0]
+
+	t.Run("types across modules", func(t *testing.T) {
+		b := prog.PackageOf("example.com/b/sub")
+		NewWithT(t).Expect(b.Field("B", "Name")).NotTo(BeNil())
+	})
+
+	t.Run("modules", func(t *testing.T) {
+		modules := prog.Modules()
+		NewWithT(t).Expect(modules).To(HaveLen(2))
+		NewWithT(t).Expect(modules[0].Path).To(Equal("example.com/a"))
+		NewWithT(t).Expect(modules[1].Path).To(Equal("example.com/b"))
+
+		NewWithT(t).Expect(prog.ModuleOf("example.com/b/sub").Path).To(Equal("example.com/b"))
+		NewWithT(t).Expect(prog.ModuleOf("example.com/b/not/loaded").Path).To(Equal("example.com/b"))
+		NewWithT(t).Expect(prog.ModuleOf("fmt")).To(BeNil())
+
+		NewWithT(t).Expect(prog.ModuleRoot("example.com/a")).To(Equal(filepath.Join(dir, "a")))
+		NewWithT(t).Expect(prog.ModuleRoot("example.com/b/sub")).To(Equal(filepath.Join(dir, "b")))
+		NewWithT(t).Expect(prog.ModuleVersion("example.com/a")).To(Equal(""))
+	})
+
+	t.Run("without go.work", func(t *testing.T) {
+		_, err := LoadWorkspace(&packages.Config{Dir: t.TempDir()}, "./...")
+		NewWithT(t).Expect(err).NotTo(BeNil())
+	})
+}